@@ -0,0 +1,291 @@
+package jsonlog
+
+import (
+	"bufio"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OverflowPolicy selects what an async output does when its queue is full
+type OverflowPolicy string
+
+const (
+	OverflowBlock   OverflowPolicy = "block"    // wait for the flusher
+	OverflowDropNew OverflowPolicy = "drop_new" // discard the entry being written
+	OverflowDropOld OverflowPolicy = "drop_old" // discard the oldest queued entry
+)
+
+// AsyncConfig holds the settings of asynchronous output. Entries are encoded
+// by the caller and queued; a background flusher writes them out.
+type AsyncConfig struct {
+	// QueueSize is the number of entries each output can queue (0 = 1024)
+	QueueSize int
+
+	// FlushInterval is the longest an entry waits before it is written
+	// (0 = 100ms). A queue that is half full is flushed at once.
+	FlushInterval time.Duration
+
+	// Overflow is the policy when a queue is full (empty = OverflowBlock)
+	Overflow OverflowPolicy
+}
+
+const (
+	defaultQueueSize     = 1024
+	defaultFlushInterval = 100 * time.Millisecond
+)
+
+// AsyncStats reports the activity of the async outputs
+type AsyncStats struct {
+	Queued  int    // entries waiting to be written
+	Written uint64 // entries written out
+	Dropped uint64 // entries discarded because a queue was full
+	Failed  uint64 // entries lost because writing them out failed
+}
+
+// asyncWriter queues writes in a ring buffer for a background flusher
+type asyncWriter struct {
+	out      zapcore.WriteSyncer
+	buffered *bufio.Writer
+	policy   OverflowPolicy
+	interval time.Duration
+	each     bool // write every entry with its own call, as datagrams need
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	queue   [][]byte
+	head    int
+	count   int
+	closed  bool
+	written uint64
+	dropped uint64
+	failed  uint64 // entries of a batch given up after a failed write
+	err     error  // last write error, reported by Sync and close
+
+	flushMu sync.Mutex // serializes writes to out
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// validateAsync applies the defaults and checks the settings
+func validateAsync(config AsyncConfig) (AsyncConfig, error) {
+	if config.QueueSize < 0 {
+		return config, fmt.Errorf("QueueSize must not be negative, got %d", config.QueueSize)
+	}
+	if config.QueueSize == 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.FlushInterval < 0 {
+		return config, fmt.Errorf("FlushInterval must not be negative, got %s", config.FlushInterval)
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	switch config.Overflow {
+	case "":
+		config.Overflow = OverflowBlock
+	case OverflowBlock, OverflowDropNew, OverflowDropOld:
+	default:
+		return config, fmt.Errorf("unknown Overflow policy %q", config.Overflow)
+	}
+	return config, nil
+}
+
+// newAsyncWriter queues writes to out until start is called; config must
+// be validated
+func newAsyncWriter(out zapcore.WriteSyncer, config AsyncConfig) *asyncWriter {
+	w := &asyncWriter{
+		out:      out,
+		buffered: bufio.NewWriter(out),
+		policy:   config.Overflow,
+		interval: config.FlushInterval,
+		queue:    make([][]byte, config.QueueSize),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	return w
+}
+
+// start runs the background flusher
+func (w *asyncWriter) start() {
+	go w.run()
+}
+
+// Write queues a copy of p. Once the writer is closed it writes through.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	for !w.closed && w.count == len(w.queue) {
+		switch w.policy {
+		case OverflowDropNew:
+			w.dropped++
+			w.mu.Unlock()
+			return len(p), nil
+		case OverflowDropOld:
+			w.queue[w.head] = nil
+			w.head = (w.head + 1) % len(w.queue)
+			w.count--
+			w.dropped++
+		default:
+			w.notFull.Wait()
+		}
+	}
+
+	if w.closed {
+		w.mu.Unlock()
+		return w.writeThrough(p)
+	}
+
+	// zap reuses p once Write returns
+	w.queue[(w.head+w.count)%len(w.queue)] = append([]byte(nil), p...)
+	w.count++
+	if w.count >= (len(w.queue)+1)/2 {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+func (w *asyncWriter) writeThrough(p []byte) (int, error) {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	// Keep order with entries queued before the writer was closed
+	if err := w.flushLocked(); err != nil {
+		return 0, err
+	}
+	n, err := w.out.Write(p)
+	w.mu.Lock()
+	if err == nil {
+		w.written++
+	} else {
+		w.failed++
+	}
+	w.mu.Unlock()
+	return n, err
+}
+
+// run flushes the queue every interval, when woken and once on close
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.wake:
+		case <-w.stop:
+			w.flush()
+			return
+		}
+		w.flush()
+	}
+}
+
+// flush writes out everything queued so far, in order
+func (w *asyncWriter) flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	return w.flushLocked()
+}
+
+// flushLocked is flush for callers holding flushMu
+func (w *asyncWriter) flushLocked() error {
+	w.mu.Lock()
+	batch := make([][]byte, w.count)
+	for i := range batch {
+		index := (w.head + i) % len(w.queue)
+		batch[i] = w.queue[index]
+		w.queue[index] = nil
+	}
+	w.head, w.count = 0, 0
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	var err error
+	written := 0
+	for _, p := range batch {
+		if _, err = w.buffered.Write(p); err == nil && w.each {
+			err = w.buffered.Flush()
+		}
+		if err != nil {
+			break
+		}
+		written++
+	}
+	if err == nil {
+		err = w.buffered.Flush()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		// A failed bufio.Writer stays failed; start over for the next batch
+		w.buffered.Reset(w.out)
+		w.err = err
+		if !w.each {
+			// Buffered entries may have been written in part; which ones is
+			// unknown, so the whole batch counts as failed
+			written = 0
+		}
+		w.written += uint64(written)
+		w.failed += uint64(len(batch) - written)
+		return err
+	}
+	w.written += uint64(len(batch))
+	return nil
+}
+
+// Sync writes out the queue and syncs the underlying writer
+func (w *asyncWriter) Sync() error {
+	if err := w.drain(); err != nil {
+		return err
+	}
+	return w.out.Sync()
+}
+
+// drain writes out the queue and returns the last write error since the
+// previous call
+func (w *asyncWriter) drain() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// close stops the flusher after it has written out the queue. Later
+// writes go straight to the underlying writer. Syncing is left to the
+// caller.
+func (w *asyncWriter) close() error {
+	w.once.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		close(w.stop)
+		<-w.done
+	})
+	return w.drain()
+}
+
+// stats returns the writer's counters
+func (w *asyncWriter) stats() AsyncStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return AsyncStats{Queued: w.count, Written: w.written, Dropped: w.dropped, Failed: w.failed}
+}
@@ -0,0 +1,208 @@
+package jsonlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// syncBuffer is a bytes.Buffer safe for the flusher goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAsyncLogger(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Async:       &AsyncConfig{QueueSize: 16},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		logger.Info("async message", zap.Int("n", i))
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 100 {
+		t.Fatalf("expected 100 records, got %d", len(records))
+	}
+	for i, record := range records {
+		if record["n"] != float64(i) {
+			t.Fatalf("record %d out of order: %v", i, record["n"])
+		}
+	}
+
+	stats := logger.AsyncStats()
+	if stats.Written != 100 || stats.Queued != 0 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestAsyncFlushInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Async:       &AsyncConfig{FlushInterval: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("flushed without Close")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+		if err == nil && strings.Contains(string(content), "flushed without Close") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("entry was not flushed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	tests := []struct {
+		policy  OverflowPolicy
+		want    string
+		dropped uint64
+	}{
+		{OverflowDropNew, "012", 2},
+		{OverflowDropOld, "234", 2},
+		{OverflowBlock, "01234", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			out := &syncBuffer{}
+			config, err := validateAsync(AsyncConfig{QueueSize: 3, Overflow: tt.policy})
+			if err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+			// The flusher is not started yet, so the queue fills up
+			w := newAsyncWriter(zapcore.AddSync(out), config)
+
+			written := make(chan struct{})
+			go func() {
+				defer close(written)
+				for i := 0; i < 5; i++ {
+					fmt.Fprint(w, i)
+				}
+			}()
+
+			if tt.policy == OverflowBlock {
+				select {
+				case <-written:
+					t.Fatal("writes should block while the queue is full")
+				case <-time.After(50 * time.Millisecond):
+				}
+			} else {
+				<-written
+			}
+			w.start()
+			<-written
+
+			if err := w.close(); err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("written %q, want %q", got, tt.want)
+			}
+			if stats := w.stats(); stats.Dropped != tt.dropped || stats.Queued != 0 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+
+			// Writes after close go straight through
+			fmt.Fprint(w, "x")
+			if got := out.String(); got != tt.want+"x" {
+				t.Errorf("write after close: %q", got)
+			}
+		})
+	}
+}
+
+// failingWriteSyncer fails every write, as a full disk does
+type failingWriteSyncer struct{}
+
+func (failingWriteSyncer) Write([]byte) (int, error) { return 0, errors.New("no space left on device") }
+func (failingWriteSyncer) Sync() error               { return nil }
+
+func TestAsyncWriteFailure(t *testing.T) {
+	config, err := validateAsync(AsyncConfig{})
+	if err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	w := newAsyncWriter(failingWriteSyncer{}, config)
+	w.start()
+	for i := 0; i < 3; i++ {
+		fmt.Fprint(w, i)
+	}
+
+	if err := w.close(); err == nil || !strings.Contains(err.Error(), "no space left") {
+		t.Errorf("close should report the write error, got %v", err)
+	}
+	if stats := w.stats(); stats.Failed != 3 || stats.Written != 0 || stats.Queued != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// A failed write after close counts as well
+	fmt.Fprint(w, "x")
+	if stats := w.stats(); stats.Failed != 4 {
+		t.Errorf("unexpected stats after close: %+v", stats)
+	}
+}
+
+func TestAsyncValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config AsyncConfig
+	}{
+		{"negative queue size", AsyncConfig{QueueSize: -1}},
+		{"negative flush interval", AsyncConfig{FlushInterval: -time.Second}},
+		{"unknown policy", AsyncConfig{Overflow: "spill"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			if _, err := NewLogger(Config{LogPath: t.TempDir(), Async: &config}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/gusdeyw/jsonlog-go"
+)
+
+// runCat prints the matching entries of files
+func runCat(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	var filterOpts filterOptions
+	var outputOpts outputOptions
+	flags := newFlagSet("cat", "cat [flags] [file...]", stderr)
+	filterOpts.register(flags)
+	outputOpts.register(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return exitError, err
+	}
+
+	schema, filter, err := prepare(&filterOpts, &outputOpts)
+	if err != nil {
+		return exitError, err
+	}
+
+	p := newPrinter(outputOpts, stdout)
+	err = scanInputs(ctx, flags.Args(), stdin, stderr, filter, schema, p.print)
+	if flushErr := p.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return exitError, err
+	}
+	return exitOK, nil
+}
+
+// runGrep prints the matching entries whose message, or -key field,
+// matches a regular expression
+func runGrep(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	var filterOpts filterOptions
+	var outputOpts outputOptions
+	flags := newFlagSet("grep", "grep [flags] pattern [file...]", stderr)
+	filterOpts.register(flags)
+	outputOpts.register(flags)
+	key := flags.String("key", "", "match this field instead of the message; dotted keys reach into objects")
+	ignoreCase := flags.Bool("i", false, "match case-insensitively")
+	invert := flags.Bool("v", false, "print the entries that do not match")
+	count := flags.Bool("c", false, "print only the number of matching entries")
+	if err := parseFlags(flags, args); err != nil {
+		return exitError, err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitError, errUsage
+	}
+
+	pattern := flags.Arg(0)
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return exitError, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	schema, filter, err := prepare(&filterOpts, &outputOpts)
+	if err != nil {
+		return exitError, err
+	}
+	match := func(log map[string]interface{}) bool {
+		return matchEntry(schema, log, *key, re) != *invert
+	}
+	if filter == nil {
+		filter = match
+	} else {
+		filter = jsonlog.And(filter, match)
+	}
+
+	matched := 0
+	p := newPrinter(outputOpts, stdout)
+	err = scanInputs(ctx, flags.Args()[1:], stdin, stderr, filter, schema, func(reader *jsonlog.Reader) error {
+		matched++
+		if *count {
+			return nil
+		}
+		return p.print(reader)
+	})
+	if err == nil && *count {
+		fmt.Fprintln(p.out, matched)
+	}
+	if flushErr := p.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return exitError, err
+	}
+	if matched == 0 {
+		return exitNoMatch, nil
+	}
+	return exitOK, nil
+}
+
+// matchEntry reports whether the message of log, or its field at key,
+// matches re. Non-string fields are matched in their printed form.
+func matchEntry(schema jsonlog.Schema, log map[string]interface{}, key string, re *regexp.Regexp) bool {
+	entry := schema.NewLogEntry(log)
+	if key == "" {
+		return re.MatchString(entry.Message)
+	}
+	value, ok := entry.Field(key)
+	if !ok {
+		// Standard keys such as logger or caller are not in Fields
+		if value, ok = log[key]; !ok {
+			return false
+		}
+	}
+	if s, ok := value.(string); ok {
+		return re.MatchString(s)
+	}
+	return re.MatchString(formatValue(value))
+}
+
+// prepare validates the shared flags and builds the schema and filter
+func prepare(filterOpts *filterOptions, outputOpts *outputOptions) (jsonlog.Schema, jsonlog.FilterFunc, error) {
+	if outputOpts != nil {
+		if err := outputOpts.validate(); err != nil {
+			return jsonlog.Schema{}, nil, err
+		}
+	}
+	schema, err := filterOpts.schema()
+	if err != nil {
+		return jsonlog.Schema{}, nil, err
+	}
+	filter, err := filterOpts.filter(schema, time.Now())
+	if err != nil {
+		return jsonlog.Schema{}, nil, err
+	}
+	return schema, filter, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gusdeyw/jsonlog-go"
+)
+
+// farFuture closes time ranges given only -since
+var farFuture = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// timeLayouts are accepted by -since and -until besides durations
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// levels lists the level names from least to most severe
+var levels = []jsonlog.LogLevel{
+	jsonlog.DebugLevel,
+	jsonlog.InfoLevel,
+	jsonlog.WarnLevel,
+	jsonlog.ErrorLevel,
+	jsonlog.FatalLevel,
+	jsonlog.PanicLevel,
+}
+
+// fieldFlag collects repeated -field key=value flags
+type fieldFlag []string
+
+func (f *fieldFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *fieldFlag) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+// filterOptions holds the flags shared by every command that selects entries
+type filterOptions struct {
+	level  string
+	since  string
+	until  string
+	fields fieldFlag
+	where  string
+	preset string
+}
+
+func (o *filterOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.level, "level", "", "minimum level, e.g. warn keeps warn, error, fatal and panic")
+	flags.StringVar(&o.since, "since", "", "keep entries at or after this time (RFC 3339, a date, or a duration ago such as 2h)")
+	flags.StringVar(&o.until, "until", "", "keep entries before this time (same formats as -since)")
+	flags.Var(&o.fields, "field", "keep entries whose field equals a value, as key=value; repeatable, dotted keys reach into objects")
+	flags.StringVar(&o.where, "where", "", `query such as 'status>=500 AND path~"^/api"' (see jsonlog.ParseQuery)`)
+	flags.StringVar(&o.preset, "preset", "", "read records written with a preset layout: ecs or otel")
+}
+
+// schema returns the record schema selected by -preset
+func (o *filterOptions) schema() (jsonlog.Schema, error) {
+	if o.preset == "" {
+		return jsonlog.DefaultSchema(), nil
+	}
+	return jsonlog.Preset(o.preset).Schema()
+}
+
+// filter combines the selected filters; nil means every entry
+func (o *filterOptions) filter(schema jsonlog.Schema, now time.Time) (jsonlog.FilterFunc, error) {
+	var filters []jsonlog.FilterFunc
+
+	if o.level != "" {
+		level, ok := parseLevel(o.level)
+		if !ok {
+			return nil, fmt.Errorf("invalid -level %q", o.level)
+		}
+		filters = append(filters, schema.FilterByMinLevel(level))
+	}
+
+	if o.since != "" || o.until != "" {
+		start, end := time.Time{}, farFuture
+		if o.since != "" {
+			since, err := parseTimeFlag(o.since, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid -since: %w", err)
+			}
+			start = since.Add(-time.Nanosecond) // FilterByTimeRange excludes start
+		}
+		if o.until != "" {
+			until, err := parseTimeFlag(o.until, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid -until: %w", err)
+			}
+			end = until
+		}
+		filters = append(filters, schema.FilterByTimeRange(start, end))
+	}
+
+	for _, field := range o.fields {
+		key, value, _ := strings.Cut(field, "=")
+		filters = append(filters, jsonlog.FieldEquals(key, parseFieldValue(value)))
+	}
+
+	if o.where != "" {
+		query, err := schema.ParseQuery(o.where)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -where: %w", err)
+		}
+		filters = append(filters, query)
+	}
+
+	switch len(filters) {
+	case 0:
+		return nil, nil
+	case 1:
+		return filters[0], nil
+	default:
+		return jsonlog.And(filters...), nil
+	}
+}
+
+// parseLevel reads a level name in any case; "warning" means warn
+func parseLevel(name string) (jsonlog.LogLevel, bool) {
+	level := jsonlog.LogLevel(strings.ToLower(name))
+	if level == "warning" {
+		level = jsonlog.WarnLevel
+	}
+	for _, known := range levels {
+		if level == known {
+			return level, true
+		}
+	}
+	return "", false
+}
+
+// parseTimeFlag reads an absolute time, or a duration before now
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a time nor a duration", value)
+}
+
+// parseFieldValue reads numbers, booleans and null as JSON, so -field
+// status=500 matches the number 500; anything else is a string
+func parseFieldValue(value string) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+		switch decoded.(type) {
+		case float64, bool, nil:
+			return decoded
+		}
+	}
+	return value
+}
@@ -0,0 +1,171 @@
+// Command jsonlog reads, filters, follows and summarizes jsonlog files.
+//
+//	jsonlog cat   [flags] [file...]          print entries
+//	jsonlog grep  [flags] pattern [file...]  print entries whose message matches pattern
+//	jsonlog tail  [flags] file               print the last entries, -f to follow
+//	jsonlog stats [flags] [file...]          count entries per level and per message
+//
+// Files may be plain or gzip; with no file (or "-") standard input is read.
+// Entries are filtered with -level, -since, -until, -field and -where, and
+// printed as readable lines or, with -o json, as NDJSON.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gusdeyw/jsonlog-go"
+)
+
+const usage = `Usage: jsonlog <command> [flags] [file...]
+
+Commands:
+  cat    print the entries of log files
+  grep   print the entries whose message matches a regular expression
+  tail   print the last entries of a log file; -f follows it across rotations
+  stats  count entries per level and per message
+
+Run "jsonlog <command> -h" for the flags of a command.
+`
+
+// Exit statuses; grep follows grep(1) and exits 1 when nothing matched
+const (
+	exitOK          = 0
+	exitNoMatch     = 1
+	exitError       = 2
+	exitInterrupted = 130
+)
+
+// errUsage reports bad arguments; the flag set has already printed why
+var errUsage = errors.New("invalid arguments")
+
+// command runs one subcommand and returns its exit status
+type command func(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+
+var commands = map[string]command{
+	"cat":   runCat,
+	"grep":  runGrep,
+	"tail":  runTail,
+	"stats": runStats,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run dispatches args to a subcommand and reports its error on stderr
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return exitError
+		}
+		return exitOK
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "jsonlog: unknown command %q\n\n%s", args[0], usage)
+		return exitError
+	}
+
+	status, err := cmd(ctx, args[1:], stdin, stdout, stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitError
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case err != nil:
+		fmt.Fprintf(stderr, "jsonlog %s: %v\n", args[0], err)
+		return exitError
+	}
+	return status
+}
+
+// newFlagSet creates the flag set of a subcommand, printing its errors and
+// usage to stderr
+func newFlagSet(name, synopsis string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: jsonlog %s\n\nFlags:\n", synopsis)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args, mapping flag errors other than -h to errUsage
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// scanInputs streams the matching entries of every file in order, or of
+// stdin when files is empty or "-", until ctx is done. Malformed lines are
+// reported on stderr and skipped.
+func scanInputs(ctx context.Context, files []string, stdin io.Reader, stderr io.Writer, filter jsonlog.FilterFunc, schema jsonlog.Schema, fn func(reader *jsonlog.Reader) error) error {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, file := range files {
+		var reader *jsonlog.Reader
+		if file == "-" {
+			reader = jsonlog.NewReader(stdin, filter)
+		} else {
+			var err error
+			if reader, err = jsonlog.OpenLogFile(file, filter); err != nil {
+				return err
+			}
+		}
+		reader.Schema = schema
+		reader.OnMalformed = func(err *jsonlog.MalformedLineError) {
+			reportMalformed(stderr, err)
+		}
+
+		err := scanReader(ctx, reader, fn)
+		if closeErr := reader.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanReader(ctx context.Context, reader *jsonlog.Reader, fn func(reader *jsonlog.Reader) error) error {
+	for reader.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(reader); err != nil {
+			return err
+		}
+	}
+	return reader.Err()
+}
+
+// reportMalformed warns about a skipped line
+func reportMalformed(stderr io.Writer, err *jsonlog.MalformedLineError) {
+	file := err.File
+	if file == "" {
+		file = "<stdin>"
+	}
+	fmt.Fprintf(stderr, "jsonlog: %s:%d: skipping malformed line: %v\n", file, err.Line, err.Err)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleLog = `{"level":"debug","timestamp":"2025-12-02T10:00:00.000Z","message":"cache miss","key":"user:1"}
+{"level":"info","timestamp":"2025-12-02T10:00:01.000Z","logger":"http","message":"request served","status":200,"path":"/api/users"}
+{"level":"warn","timestamp":"2025-12-02T10:00:02.000Z","logger":"http","message":"slow request","status":200,"elapsed":"2.5s"}
+not json
+{"level":"error","timestamp":"2025-12-02T10:00:03.000Z","logger":"db","message":"query failed","error":"connection reset","stacktrace":"main.query\n\tdb.go:10"}
+{"level":"info","timestamp":"2025-12-02T10:00:04.000Z","logger":"http","message":"request served","status":500,"path":"/api/orders"}
+`
+
+// writeLog writes content to name in a temporary directory, gzipped when
+// name ends in .gz
+func writeLog(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+
+	data := []byte(content)
+	if strings.HasSuffix(name, ".gz") {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+		data = buf.Bytes()
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+// runCommand runs the CLI with args and returns its status and output
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	status := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+// messages returns the message of each NDJSON line in output
+func messages(t *testing.T, output string) []string {
+	t.Helper()
+	var result []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("output is not NDJSON: %q", line)
+		}
+		result = append(result, entry["message"].(string))
+	}
+	return result
+}
+
+func TestCat(t *testing.T) {
+	plain := writeLog(t, "app.log", sampleLog)
+	archive := writeLog(t, "app.log.gz", sampleLog)
+
+	status, stdout, stderr := runCommand(t, "", "cat", "-o", "json", plain, archive)
+	if status != exitOK {
+		t.Fatalf("status = %d, stderr: %s", status, stderr)
+	}
+	if got := messages(t, stdout); len(got) != 10 {
+		t.Errorf("expected 10 entries from both files, got %d: %v", len(got), got)
+	}
+	if strings.Count(stderr, "skipping malformed line") != 2 || !strings.Contains(stderr, "app.log:4:") {
+		t.Errorf("malformed lines should be reported with their position: %q", stderr)
+	}
+
+	// Standard input
+	status, stdout, _ = runCommand(t, sampleLog, "cat", "-o", "json", "-level", "error")
+	if got := messages(t, stdout); status != exitOK || len(got) != 1 || got[0] != "query failed" {
+		t.Errorf("stdin: status %d, messages %v", status, got)
+	}
+}
+
+func TestCatFilters(t *testing.T) {
+	path := writeLog(t, "app.log", sampleLog)
+
+	tests := []struct {
+		name  string
+		flags []string
+		want  []string
+	}{
+		{"min level", []string{"-level", "WARNING"}, []string{"slow request", "query failed"}},
+		{"since", []string{"-since", "2025-12-02T10:00:03Z"}, []string{"query failed", "request served"}},
+		{"time range", []string{"-since", "2025-12-02T10:00:01Z", "-until", "2025-12-02T10:00:03Z"}, []string{"request served", "slow request"}},
+		{"field number", []string{"-field", "status=500"}, []string{"request served"}},
+		{"fields", []string{"-field", "logger=http", "-field", "status=200"}, []string{"request served", "slow request"}},
+		{"query", []string{"-where", `path~"orders" OR level>=error`}, []string{"query failed", "request served"}},
+		{"duration ago", []string{"-since", "1h"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"cat", "-o", "json"}, tt.flags...)
+			status, stdout, stderr := runCommand(t, "", append(args, path)...)
+			if status != exitOK {
+				t.Fatalf("status = %d, stderr: %s", status, stderr)
+			}
+			if got := messages(t, stdout); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatPretty(t *testing.T) {
+	path := writeLog(t, "app.log", sampleLog)
+	status, stdout, _ := runCommand(t, "", "cat", "-utc", "-level", "warn", path)
+	if status != exitOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	want := "2025-12-02 10:00:02.000 WARN  [http] slow request  elapsed=2.5s status=200\n" +
+		"2025-12-02 10:00:03.000 ERROR [db] query failed  error=\"connection reset\"\n" +
+		"    main.query\n" +
+		"    \tdb.go:10\n"
+	if stdout != want {
+		t.Errorf("pretty output:\n%s\nwant:\n%s", stdout, want)
+	}
+}
+
+func TestCatPreset(t *testing.T) {
+	path := writeLog(t, "app.log",
+		`{"@timestamp":"2025-12-02T10:00:00Z","log.level":"info","message":"started","ecs.version":"8.11.0"}
+{"@timestamp":"2025-12-02T10:00:01Z","log.level":"error","message":"crashed","ecs.version":"8.11.0"}
+`)
+	status, stdout, _ := runCommand(t, "", "cat", "-preset", "ecs", "-level", "error", "-utc", path)
+	if status != exitOK || stdout != "2025-12-02 10:00:01.000 ERROR crashed\n" {
+		t.Errorf("status %d, output %q", status, stdout)
+	}
+}
+
+func TestGrep(t *testing.T) {
+	path := writeLog(t, "app.log", sampleLog)
+
+	status, stdout, _ := runCommand(t, "", "grep", "-o", "json", "-i", "REQUEST", path)
+	if got := messages(t, stdout); status != exitOK || len(got) != 3 {
+		t.Errorf("status %d, messages %v", status, got)
+	}
+
+	status, stdout, _ = runCommand(t, "", "grep", "-o", "json", "-key", "path", "^/api/o", path)
+	if got := messages(t, stdout); status != exitOK || len(got) != 1 {
+		t.Errorf("-key: status %d, messages %v", status, got)
+	}
+
+	status, stdout, _ = runCommand(t, "", "grep", "-c", "-v", "-level", "info", "request", path)
+	if status != exitOK || stdout != "1\n" {
+		t.Errorf("-c -v: status %d, output %q", status, stdout)
+	}
+
+	status, stdout, _ = runCommand(t, "", "grep", "no such message", path)
+	if status != exitNoMatch || stdout != "" {
+		t.Errorf("no match: status %d, output %q", status, stdout)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	path := writeLog(t, "app.log", sampleLog)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no command", nil, "Usage: jsonlog <command>"},
+		{"unknown command", []string{"less"}, `unknown command "less"`},
+		{"unknown flag", []string{"cat", "-x", path}, "flag provided but not defined"},
+		{"missing pattern", []string{"grep"}, "Usage: jsonlog grep"},
+		{"bad pattern", []string{"grep", "(", path}, "invalid pattern"},
+		{"bad level", []string{"cat", "-level", "loud", path}, `invalid -level "loud"`},
+		{"bad time", []string{"cat", "-since", "yesterday", path}, "invalid -since"},
+		{"bad query", []string{"cat", "-where", "status >", path}, "invalid -where"},
+		{"bad field", []string{"cat", "-field", "status", path}, "expected key=value"},
+		{"bad preset", []string{"cat", "-preset", "gelf", path}, `unknown preset "gelf"`},
+		{"bad output", []string{"cat", "-o", "yaml", path}, `unknown output format "yaml"`},
+		{"missing file", []string{"cat", filepath.Join(t.TempDir(), "missing.log")}, "failed to open log file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, stderr := runCommand(t, "", tt.args...)
+			if status != exitError || !strings.Contains(stderr, tt.want) {
+				t.Errorf("status %d, stderr %q, want %q", status, stderr, tt.want)
+			}
+		})
+	}
+
+	if status, _, _ := runCommand(t, "", "cat", "-h"); status != exitOK {
+		t.Errorf("-h should exit 0, got %d", status)
+	}
+}
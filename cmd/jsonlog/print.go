@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gusdeyw/jsonlog-go"
+)
+
+// Output formats selected with -o
+const (
+	prettyOutput = "pretty"
+	jsonOutput   = "json"
+)
+
+// prettyTimeFormat is the timestamp of pretty output, in the local zone
+// unless -utc is set
+const prettyTimeFormat = "2006-01-02 15:04:05.000"
+
+// outputOptions holds the flags that choose how entries are printed
+type outputOptions struct {
+	format string
+	utc    bool
+}
+
+func (o *outputOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.format, "o", prettyOutput, "output format: pretty or json (NDJSON, one entry per line as read)")
+	flags.BoolVar(&o.utc, "utc", false, "print pretty timestamps in UTC instead of local time")
+}
+
+func (o *outputOptions) validate() error {
+	if o.format != prettyOutput && o.format != jsonOutput {
+		return fmt.Errorf("unknown output format %q (want pretty or json)", o.format)
+	}
+	return nil
+}
+
+// printer writes entries to a buffered output; Flush must be called
+type printer struct {
+	options outputOptions
+	out     *bufio.Writer
+}
+
+func newPrinter(options outputOptions, w io.Writer) *printer {
+	return &printer{options: options, out: bufio.NewWriter(w)}
+}
+
+// print writes the current entry of reader
+func (p *printer) print(reader *jsonlog.Reader) error {
+	return p.printEntry(reader.Entry(), reader.LogEntry())
+}
+
+// printEntry writes one entry; raw is printed as NDJSON, entry as a line of
+// text
+func (p *printer) printEntry(raw map[string]interface{}, entry jsonlog.LogEntry) error {
+	if p.options.format == jsonOutput {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("failed to encode entry: %w", err)
+		}
+		p.out.Write(data)
+		return p.out.WriteByte('\n')
+	}
+
+	_, err := p.out.WriteString(p.pretty(entry))
+	return err
+}
+
+func (p *printer) Flush() error {
+	return p.out.Flush()
+}
+
+// pretty formats an entry as
+//
+//	2025-12-02 15:59:57.317 WARN  [http] disk slow  device=sda latency_ms=120  caller=main.go:42
+//
+// followed by its indented stack trace, if any
+func (p *printer) pretty(entry jsonlog.LogEntry) string {
+	var b strings.Builder
+
+	if !entry.Time.IsZero() {
+		t := entry.Time.Local()
+		if p.options.utc {
+			t = entry.Time.UTC()
+		}
+		b.WriteString(t.Format(prettyTimeFormat))
+		b.WriteByte(' ')
+	}
+	fmt.Fprintf(&b, "%-5s ", strings.ToUpper(string(entry.Level)))
+	if entry.Logger != "" {
+		fmt.Fprintf(&b, "[%s] ", entry.Logger)
+	}
+	b.WriteString(entry.Message)
+
+	if len(entry.Fields) > 0 {
+		keys := make([]string, 0, len(entry.Fields))
+		for key := range entry.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b.WriteString(" ")
+		for _, key := range keys {
+			fmt.Fprintf(&b, " %s=%s", key, formatValue(entry.Fields[key]))
+		}
+	}
+	if entry.Caller != "" {
+		fmt.Fprintf(&b, "  caller=%s", entry.Caller)
+	}
+	b.WriteByte('\n')
+
+	if entry.Stacktrace != "" {
+		for _, line := range strings.Split(strings.TrimRight(entry.Stacktrace, "\n"), "\n") {
+			b.WriteString("    ")
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// formatValue prints plain strings as they are and everything else, or
+// strings that would be ambiguous, as JSON
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok && s != "" && !strings.ContainsAny(s, " \t\r\n\"=") {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
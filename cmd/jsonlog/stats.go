@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gusdeyw/jsonlog-go"
+)
+
+// stats summarizes the matching entries of a set of files
+type stats struct {
+	Entries  int            `json:"entries"`
+	First    time.Time      `json:"first"`
+	Last     time.Time      `json:"last"`
+	Levels   []countedValue `json:"levels"`
+	Messages []countedValue `json:"messages"`
+	Loggers  []countedValue `json:"loggers,omitempty"`
+
+	levels   map[string]int
+	messages map[string]int
+	loggers  map[string]int
+}
+
+// countedValue is a value and the number of entries that have it
+type countedValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// runStats prints the number of matching entries per level, message and
+// logger, and the time span they cover
+func runStats(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	var filterOpts filterOptions
+	flags := newFlagSet("stats", "stats [flags] [file...]", stderr)
+	filterOpts.register(flags)
+	format := flags.String("o", prettyOutput, "output format: pretty (tables) or json")
+	top := flags.Int("top", 20, "number of messages and loggers to list, most frequent first (0 = all)")
+	if err := parseFlags(flags, args); err != nil {
+		return exitError, err
+	}
+	if *top < 0 {
+		flags.Usage()
+		return exitError, errUsage
+	}
+	outputOpts := outputOptions{format: *format}
+	schema, filter, err := prepare(&filterOpts, &outputOpts)
+	if err != nil {
+		return exitError, err
+	}
+
+	s := &stats{levels: map[string]int{}, messages: map[string]int{}, loggers: map[string]int{}}
+	err = scanInputs(ctx, flags.Args(), stdin, stderr, filter, schema, func(reader *jsonlog.Reader) error {
+		s.add(reader.LogEntry())
+		return nil
+	})
+	if err != nil {
+		return exitError, err
+	}
+	s.summarize(*top)
+
+	if *format == jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(s); err != nil {
+			return exitError, fmt.Errorf("failed to encode stats: %w", err)
+		}
+		return exitOK, nil
+	}
+	if err := s.print(stdout); err != nil {
+		return exitError, err
+	}
+	return exitOK, nil
+}
+
+func (s *stats) add(entry jsonlog.LogEntry) {
+	s.Entries++
+	if !entry.Time.IsZero() {
+		if s.First.IsZero() || entry.Time.Before(s.First) {
+			s.First = entry.Time
+		}
+		if entry.Time.After(s.Last) {
+			s.Last = entry.Time
+		}
+	}
+
+	level := string(entry.Level)
+	if level == "" {
+		level = "(none)"
+	}
+	s.levels[level]++
+	s.messages[entry.Message]++
+	if entry.Logger != "" {
+		s.loggers[entry.Logger]++
+	}
+}
+
+// summarize sorts the counts: levels by severity, messages and loggers by
+// count, keeping the top ones
+func (s *stats) summarize(top int) {
+	rank := make(map[string]int, len(levels))
+	for i, level := range levels {
+		rank[string(level)] = i
+	}
+	s.Levels = sortCounts(s.levels, 0, func(a, b countedValue) bool {
+		ra, knownA := rank[a.Value]
+		rb, knownB := rank[b.Value]
+		if knownA != knownB {
+			return knownA // unknown levels last
+		}
+		if knownA {
+			return ra < rb
+		}
+		return a.Value < b.Value
+	})
+
+	byCount := func(a, b countedValue) bool {
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	}
+	s.Messages = sortCounts(s.messages, top, byCount)
+	s.Loggers = sortCounts(s.loggers, top, byCount)
+}
+
+func sortCounts(counts map[string]int, top int, less func(a, b countedValue) bool) []countedValue {
+	values := make([]countedValue, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, countedValue{Value: value, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool { return less(values[i], values[j]) })
+	if top > 0 && len(values) > top {
+		values = values[:top]
+	}
+	return values
+}
+
+// print writes the stats as aligned tables
+func (s *stats) print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "entries\t%d\n", s.Entries)
+	if !s.First.IsZero() {
+		fmt.Fprintf(tw, "first\t%s\n", s.First.Format(time.RFC3339Nano))
+		fmt.Fprintf(tw, "last\t%s\n", s.Last.Format(time.RFC3339Nano))
+	}
+
+	printCounts(tw, "LEVEL", s.Levels)
+	printCounts(tw, "MESSAGE", s.Messages)
+	if len(s.Loggers) > 0 {
+		printCounts(tw, "LOGGER", s.Loggers)
+	}
+	return tw.Flush()
+}
+
+func printCounts(w io.Writer, title string, values []countedValue) {
+	fmt.Fprintf(w, "\nCOUNT\t%s\n", title)
+	for _, value := range values {
+		fmt.Fprintf(w, "%d\t%s\n", value.Count, value.Value)
+	}
+}
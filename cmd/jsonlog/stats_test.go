@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	path := writeLog(t, "app.log.gz", sampleLog+`{"level":"notice","message":"custom level"}
+`)
+
+	status, stdout, _ := runCommand(t, "", "stats", "-top", "2", path)
+	if status != exitOK {
+		t.Fatalf("status = %d", status)
+	}
+	want := `entries  6
+first    2025-12-02T10:00:00Z
+last     2025-12-02T10:00:04Z
+
+COUNT  LEVEL
+1      debug
+2      info
+1      warn
+1      error
+1      notice
+
+COUNT  MESSAGE
+2      request served
+1      cache miss
+
+COUNT  LOGGER
+3      http
+1      db
+`
+	if stdout != want {
+		t.Errorf("stats output:\n%s\nwant:\n%s", stdout, want)
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	path := writeLog(t, "app.log", sampleLog)
+
+	status, stdout, _ := runCommand(t, "", "stats", "-o", "json", "-field", "logger=http", path)
+	if status != exitOK {
+		t.Fatalf("status = %d", status)
+	}
+	var result stats
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+	}
+	if result.Entries != 3 || len(result.Levels) != 2 || result.Levels[0] != (countedValue{"info", 2}) {
+		t.Errorf("unexpected stats: %+v", result)
+	}
+	if len(result.Messages) != 2 || result.Messages[0] != (countedValue{"request served", 2}) {
+		t.Errorf("unexpected messages: %+v", result.Messages)
+	}
+	if !strings.HasPrefix(result.Last.String(), "2025-12-02 10:00:04") {
+		t.Errorf("unexpected time span: %v - %v", result.First, result.Last)
+	}
+}
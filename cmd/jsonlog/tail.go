@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/gusdeyw/jsonlog-go"
+)
+
+// defaultPollInterval is how often tail -f checks a file for new entries
+const defaultPollInterval = 250 * time.Millisecond
+
+// runTail prints the last matching entries of a file and, with -f, keeps
+// printing new ones as they are written
+func runTail(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	var filterOpts filterOptions
+	var outputOpts outputOptions
+	flags := newFlagSet("tail", "tail [flags] file", stderr)
+	filterOpts.register(flags)
+	outputOpts.register(flags)
+	lines := flags.Int("n", 10, "number of matching entries to print before following")
+	follow := flags.Bool("f", false, "keep printing new entries; follows the file across rotations")
+	poll := flags.Duration("poll", defaultPollInterval, "how often -f checks for new entries")
+	if err := parseFlags(flags, args); err != nil {
+		return exitError, err
+	}
+	if flags.NArg() != 1 || *lines < 0 || *poll <= 0 {
+		flags.Usage()
+		return exitError, errUsage
+	}
+
+	schema, filter, err := prepare(&filterOpts, &outputOpts)
+	if err != nil {
+		return exitError, err
+	}
+
+	p := newPrinter(outputOpts, stdout)
+	last := newEntryRing(*lines)
+	if !*follow {
+		err = scanInputs(ctx, flags.Args(), stdin, stderr, filter, schema, last.add)
+		if err == nil {
+			err = last.print(p)
+		}
+		if flushErr := p.Flush(); err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			return exitError, err
+		}
+		return exitOK, nil
+	}
+
+	f, err := openFollower(ctx, flags.Arg(0), *poll)
+	if err != nil {
+		return exitError, err
+	}
+	defer f.Close()
+
+	// Collect the last entries until the end of the file is first reached,
+	// then print every entry as it arrives
+	caughtUp := false
+	var printErr error
+	f.idle = func() {
+		if !caughtUp {
+			caughtUp = true
+			printErr = last.print(p)
+		}
+		if err := p.Flush(); printErr == nil {
+			printErr = err
+		}
+	}
+
+	reader := jsonlog.NewReader(f, filter)
+	reader.Schema = schema
+	reader.OnMalformed = func(err *jsonlog.MalformedLineError) {
+		err.File = flags.Arg(0)
+		reportMalformed(stderr, err)
+	}
+	for printErr == nil && reader.Next() {
+		if !caughtUp {
+			last.add(reader)
+		} else {
+			printErr = p.print(reader)
+		}
+	}
+	if printErr != nil {
+		return exitError, printErr
+	}
+	if err := reader.Err(); err != nil {
+		return exitError, err
+	}
+	if err := p.Flush(); err != nil {
+		return exitError, err
+	}
+	return exitOK, nil
+}
+
+// entryRing keeps the last entries read
+type entryRing struct {
+	raw     []map[string]interface{}
+	entries []jsonlog.LogEntry
+	size    int
+}
+
+func newEntryRing(size int) *entryRing {
+	return &entryRing{size: size}
+}
+
+func (r *entryRing) add(reader *jsonlog.Reader) error {
+	if r.size == 0 {
+		return nil
+	}
+	if len(r.raw) == r.size {
+		r.raw = r.raw[1:]
+		r.entries = r.entries[1:]
+	}
+	r.raw = append(r.raw, reader.Entry())
+	r.entries = append(r.entries, reader.LogEntry())
+	return nil
+}
+
+func (r *entryRing) print(p *printer) error {
+	for i := range r.raw {
+		if err := p.printEntry(r.raw[i], r.entries[i]); err != nil {
+			return err
+		}
+	}
+	r.raw, r.entries = nil, nil
+	return nil
+}
+
+// follower reads a log file as it grows, like tail -F. When lumberjack
+// rotates the file (renames it and creates a new one at the same path) the
+// follower finishes the old file and continues with the new one; a file
+// truncated in place is read again from the start. At the end of the data
+// it waits, calling idle, until more arrives or ctx is done, which ends
+// the stream with io.EOF.
+type follower struct {
+	ctx  context.Context
+	path string
+	poll time.Duration
+	idle func()
+
+	file *os.File
+	next *os.File // the file now at path, read once file is drained
+}
+
+func openFollower(ctx context.Context, path string, poll time.Duration) (*follower, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return &follower{ctx: ctx, path: path, poll: poll, file: file}, nil
+}
+
+func (f *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, fmt.Errorf("failed to read %s: %w", f.path, err)
+		}
+
+		// The old file is drained, so writes made before the rotation
+		// have all been read
+		if f.next != nil {
+			f.file.Close()
+			f.file, f.next = f.next, nil
+			continue
+		}
+
+		changed, err := f.checkRotation()
+		if err != nil {
+			return 0, err
+		}
+		if changed {
+			continue
+		}
+
+		if f.idle != nil {
+			f.idle()
+		}
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(f.poll):
+		}
+	}
+}
+
+// checkRotation looks for a new file at path or a truncated one and
+// reports whether there is more to read
+func (f *follower) checkRotation() (bool, error) {
+	info, err := os.Stat(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil // renamed, the new file is not created yet
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", f.path, err)
+	}
+	current, err := f.file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", f.path, err)
+	}
+
+	if !os.SameFile(info, current) {
+		next, err := os.Open(f.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to open log file: %w", err)
+		}
+		f.next = next
+		return true, nil
+	}
+
+	offset, err := f.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, fmt.Errorf("failed to seek %s: %w", f.path, err)
+	}
+	if info.Size() < offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return false, fmt.Errorf("failed to seek %s: %w", f.path, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *follower) Close() error {
+	if f.next != nil {
+		f.next.Close()
+	}
+	return f.file.Close()
+}
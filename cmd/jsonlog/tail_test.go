@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer safe for a running command and the test
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor polls until the output contains want
+func waitFor(t *testing.T, output *lockedBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q, output:\n%s", want, output.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	if _, err := io.WriteString(file, line); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := writeLog(t, "app.log", sampleLog)
+
+	status, stdout, _ := runCommand(t, "", "tail", "-o", "json", "-n", "2", path)
+	if got := messages(t, stdout); status != exitOK || strings.Join(got, "|") != "query failed|request served" {
+		t.Errorf("status %d, messages %v", status, got)
+	}
+
+	status, stdout, _ = runCommand(t, "", "tail", "-o", "json", "-n", "5", "-level", "warn", path)
+	if got := messages(t, stdout); status != exitOK || strings.Join(got, "|") != "slow request|query failed" {
+		t.Errorf("filtered: status %d, messages %v", status, got)
+	}
+}
+
+func TestTailFollow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLine(t, path, `{"level":"info","message":"old 1"}`+"\n")
+	appendLine(t, path, `{"level":"info","message":"old 2"}`+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stdout, stderr := &lockedBuffer{}, &lockedBuffer{}
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"tail", "-f", "-n", "1", "-poll", "5ms", "-o", "json", "-level", "info", path}, nil, stdout, stderr)
+	}()
+
+	waitFor(t, stdout, "old 2")
+	if strings.Contains(stdout.String(), "old 1") {
+		t.Errorf("-n 1 should skip older entries: %s", stdout.String())
+	}
+
+	// A line written in two parts is printed once complete
+	appendLine(t, path, `{"level":"info","mess`)
+	time.Sleep(20 * time.Millisecond)
+	appendLine(t, path, `age":"appended"}`+"\n")
+	appendLine(t, path, `{"level":"debug","message":"filtered out"}`+"\n")
+	waitFor(t, stdout, "appended")
+
+	// Rotation as lumberjack does it: the last write to the old file, a
+	// rename and a new file at the same path
+	appendLine(t, path, `{"level":"info","message":"before rotation"}`+"\n")
+	if err := os.Rename(path, filepath.Join(dir, "app-2025-12-02T10-00-00.000.log")); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	appendLine(t, path, `{"level":"info","message":"after rotation"}`+"\n")
+	waitFor(t, stdout, "after rotation")
+
+	// Truncation in place starts again from the top; it is noticed because
+	// the file is now shorter than what was read
+	if err := os.WriteFile(path, []byte(`{"level":"warn","message":"truncated"}`+"\n"), 0644); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+	waitFor(t, stdout, "truncated")
+
+	cancel()
+	select {
+	case status := <-done:
+		if status != exitOK {
+			t.Errorf("status = %d, stderr: %s", status, stderr.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tail -f did not stop when cancelled")
+	}
+
+	got := messages(t, stdout.String())
+	want := []string{"old 2", "appended", "before rotation", "after rotation", "truncated"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+	if stderr.String() != "" {
+		t.Errorf("unexpected errors: %s", stderr.String())
+	}
+}
@@ -0,0 +1,92 @@
+package jsonlog
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ContextExtractor returns fields to add to an entry from a context, such as
+// a trace ID or request ID. It should return nil when nothing applies.
+type ContextExtractor func(ctx context.Context) []zap.Field
+
+// ContextValueExtractor creates an extractor that writes ctx.Value(key)
+// under the given field name when the value is present
+func ContextValueExtractor(key interface{}, field string) ContextExtractor {
+	return func(ctx context.Context) []zap.Field {
+		if value := ctx.Value(key); value != nil {
+			return []zap.Field{zap.Any(field, value)}
+		}
+		return nil
+	}
+}
+
+// contextFields runs the configured extractors and appends the explicit fields
+func (l *Logger) contextFields(ctx context.Context, fields []zap.Field) []zap.Field {
+	if ctx == nil || len(l.extractors) == 0 {
+		return fields
+	}
+
+	var all []zap.Field
+	for _, extract := range l.extractors {
+		all = append(all, extract(ctx)...)
+	}
+	return append(all, fields...)
+}
+
+// DebugCtx logs a debug message with fields extracted from ctx
+func (l *Logger) DebugCtx(ctx context.Context, message string, fields ...zap.Field) {
+	l.zapLogger.Debug(message, l.contextFields(ctx, fields)...)
+}
+
+// InfoCtx logs an info message with fields extracted from ctx
+func (l *Logger) InfoCtx(ctx context.Context, message string, fields ...zap.Field) {
+	l.zapLogger.Info(message, l.contextFields(ctx, fields)...)
+}
+
+// WarnCtx logs a warning message with fields extracted from ctx
+func (l *Logger) WarnCtx(ctx context.Context, message string, fields ...zap.Field) {
+	l.zapLogger.Warn(message, l.contextFields(ctx, fields)...)
+}
+
+// ErrorCtx logs an error message with fields extracted from ctx
+func (l *Logger) ErrorCtx(ctx context.Context, message string, fields ...zap.Field) {
+	l.zapLogger.Error(message, l.contextFields(ctx, fields)...)
+}
+
+// FatalCtx logs a fatal message with fields extracted from ctx, closes the
+// logger and exits
+func (l *Logger) FatalCtx(ctx context.Context, message string, fields ...zap.Field) {
+	l.zapLogger.Fatal(message, l.contextFields(ctx, fields)...)
+}
+
+// PanicCtx logs a panic message with fields extracted from ctx
+func (l *Logger) PanicCtx(ctx context.Context, message string, fields ...zap.Field) {
+	defer l.zapLogger.Sync()
+	l.zapLogger.Panic(message, l.contextFields(ctx, fields)...)
+}
+
+// LogWithLevelCtx logs a message with specified level and fields extracted from ctx
+func (l *Logger) LogWithLevelCtx(ctx context.Context, level LogLevel, message string, fields ...zap.Field) {
+	zapLevel := levelOrInfo(level)
+	if zapLevel == zapcore.PanicLevel {
+		defer l.zapLogger.Sync()
+	}
+	if checked := l.zapLogger.Check(zapLevel, message); checked != nil {
+		checked.Write(l.contextFields(ctx, fields)...)
+	}
+}
+
+type loggerContextKey struct{}
+
+// NewContext returns a copy of ctx that carries logger
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by NewContext
+func FromContext(ctx context.Context) (*Logger, bool) {
+	logger, ok := ctx.Value(loggerContextKey{}).(*Logger)
+	return logger, ok && logger != nil
+}
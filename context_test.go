@@ -0,0 +1,77 @@
+package jsonlog
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type traceIDKey struct{}
+
+func TestContextLogging(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		ContextExtractors: []ContextExtractor{
+			ContextValueExtractor(traceIDKey{}, "trace_id"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace-abc")
+	logger.InfoCtx(ctx, "with trace", zap.String("user_id", "u1"))
+	logger.WarnCtx(context.Background(), "without trace")
+	logger.Close()
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d", len(lines))
+	}
+
+	var first, second map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if first["trace_id"] != "trace-abc" || first["user_id"] != "u1" {
+		t.Errorf("expected trace_id and user_id fields, got %v", first)
+	}
+	if _, ok := second["trace_id"]; ok {
+		t.Error("entry without trace in context should not have trace_id")
+	}
+}
+
+func TestLoggerContext(t *testing.T) {
+	logger, err := NewLogger(Config{LogPath: t.TempDir(), LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("empty context should not carry a logger")
+	}
+
+	ctx := NewContext(context.Background(), logger)
+	got, ok := FromContext(ctx)
+	if !ok || got != logger {
+		t.Error("expected the stored logger")
+	}
+}
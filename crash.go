@@ -0,0 +1,109 @@
+package jsonlog
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// exit ends the process after a fatal entry; tests replace it
+var exit = os.Exit
+
+// fatalHook closes the logger after a fatal entry is written and exits, so
+// that sinks are flushed, close hooks run and CompressOnClose happens
+type fatalHook struct {
+	logger *Logger
+}
+
+func (h fatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	h.logger.closeBeforeExit()
+	exit(1)
+}
+
+// closeBeforeExit closes the root logger, reporting failures on stderr
+// since nothing else will
+func (l *Logger) closeBeforeExit() {
+	if err := l.root.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonlog: failed to close logger before exit: %v\n", err)
+	}
+}
+
+// RecoverAction is what RecoverAndLog does after logging a panic
+type RecoverAction int
+
+const (
+	RecoverExit    RecoverAction = iota // exit with status 1
+	RecoverRepanic                      // panic again with the same value
+)
+
+// RecoverAndLog recovers a panic and logs it with the panic value and stack
+// trace. With RecoverExit it then closes the logger and exits; with
+// RecoverRepanic it flushes the logger, as Panic does, and panics again,
+// leaving the logger open for whoever recovers next. It must be deferred
+// directly, typically first thing in a goroutine:
+//
+//	go func() {
+//		defer logger.RecoverAndLog(jsonlog.RecoverExit)
+//		// ...
+//	}()
+//
+// The entry is written at fatal level for RecoverExit and panic level for
+// RecoverRepanic, with the panicking function as its caller.
+func (l *Logger) RecoverAndLog(action RecoverAction) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	level := zapcore.FatalLevel
+	if action == RecoverRepanic {
+		level = zapcore.PanicLevel
+	}
+	ent := zapcore.Entry{
+		Level:      level,
+		Time:       time.Now(),
+		Message:    "panic recovered",
+		LoggerName: l.zapLogger.Name(),
+		Caller:     panicCaller(),
+		Stack:      zap.StackSkip("", 1).String,
+	}
+
+	// Write through the core, which does not exit or panic by itself
+	if checked := l.zapLogger.Core().Check(ent, nil); checked != nil {
+		checked.Write(zap.Any("panic", value))
+	}
+
+	if action == RecoverRepanic {
+		l.zapLogger.Sync()
+		panic(value)
+	}
+	l.closeBeforeExit()
+	exit(1)
+}
+
+// panicCaller finds the function that panicked: the first frame outside
+// the runtime after runtime.gopanic
+func panicCaller() zapcore.EntryCaller {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			caller := zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			caller.Function = frame.Function
+			return caller
+		}
+		if !more {
+			return zapcore.EntryCaller{}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package jsonlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubExit replaces exit for the test and returns the recorded status codes
+func stubExit(t *testing.T) *[]int {
+	codes := &[]int{}
+	exit = func(code int) { *codes = append(*codes, code) }
+	t.Cleanup(func() { exit = os.Exit })
+	return codes
+}
+
+func TestFatalClosesBeforeExit(t *testing.T) {
+	codes := stubExit(t)
+	tmpDir := t.TempDir()
+	sink := &syncBuffer{}
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		CompressOnClose: true,
+		Async:           &AsyncConfig{FlushInterval: time.Hour},
+		Sinks:           []SinkConfig{{Writer: sink}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("before")
+	logger.Named("worker").Fatal("cannot continue")
+
+	if len(*codes) != 1 || (*codes)[0] != 1 {
+		t.Fatalf("expected exit(1), got %v", *codes)
+	}
+	entries, err := ReadCompressedLogs(filepath.Join(tmpDir, "test.log.gz"))
+	if err != nil {
+		t.Fatalf("CompressOnClose did not run: %v", err)
+	}
+	if len(entries) != 2 || entries[1]["message"] != "cannot continue" || entries[1]["level"] != "fatal" {
+		t.Errorf("unexpected archived entries: %v", entries)
+	}
+	if !strings.Contains(sink.String(), "cannot continue") {
+		t.Errorf("sink was not flushed: %q", sink.String())
+	}
+	if stats := logger.AsyncStats(); stats.Queued != 0 || stats.Written != 2 {
+		t.Errorf("async queue was not drained: %+v", stats)
+	}
+}
+
+func TestFatalWithPipedConsole(t *testing.T) {
+	codes := stubExit(t)
+	pipeStdout(t)
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		Console:         &OutputConfig{},
+		CompressOnClose: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Fatal("cannot continue")
+
+	if len(*codes) != 1 {
+		t.Fatalf("expected exit(1), got %v", *codes)
+	}
+	entries, err := ReadCompressedLogs(filepath.Join(tmpDir, "test.log.gz"))
+	if err != nil {
+		t.Fatalf("CompressOnClose did not run: %v", err)
+	}
+	if len(entries) != 1 || entries[0]["level"] != "fatal" {
+		t.Errorf("unexpected archived entries: %v", entries)
+	}
+}
+
+func TestFatalRunsCloseHooks(t *testing.T) {
+	codes := stubExit(t)
+	var ran []string
+
+	logger, err := NewLogger(Config{
+		LogPath:     t.TempDir(),
+		LogFileName: "test",
+		CloseHooks: []func() error{
+			func() error { ran = append(ran, "metrics"); return nil },
+			func() error { ran = append(ran, "tracer"); return nil },
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.With().Fatal("cannot continue")
+	if len(*codes) != 1 || strings.Join(ran, ",") != "metrics,tracer" {
+		t.Errorf("exit codes %v, hooks run %v", *codes, ran)
+	}
+}
+
+func TestPanicFlushesAndStaysOpen(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Async:       &AsyncConfig{FlushInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panic should panic")
+			}
+		}()
+		logger.Panic("bad state")
+	}()
+
+	if records := readRecords(t, filepath.Join(tmpDir, "test.log")); len(records) != 1 || records[0]["level"] != "panic" {
+		t.Fatalf("panic entry was not flushed: %v", records)
+	}
+
+	// A recovered panic leaves the logger usable
+	logger.Error("after recovery")
+	logger.Close()
+	if records := readRecords(t, filepath.Join(tmpDir, "test.log")); len(records) != 2 {
+		t.Errorf("expected 2 records, got %d", len(records))
+	}
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	t.Run("repanic", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		logger, err := NewLogger(Config{
+			LogPath:     tmpDir,
+			LogFileName: "test",
+			Async:       &AsyncConfig{FlushInterval: time.Hour},
+			Sinks:       []SinkConfig{{Writer: &syncBuffer{}}},
+		})
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		defer logger.Close()
+
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			defer logger.With().RecoverAndLog(RecoverRepanic)
+			var m map[string]int
+			m["crash"]++
+		}()
+
+		if err, ok := recovered.(error); !ok || !strings.Contains(err.Error(), "nil map") {
+			t.Errorf("expected the original panic again, got %v", recovered)
+		}
+		entries := readRecords(t, filepath.Join(tmpDir, "test.log"))
+		if len(entries) != 1 {
+			t.Fatalf("panic entry was not flushed: %v", entries)
+		}
+		entry := entries[0]
+		if entry["level"] != "panic" || entry["message"] != "panic recovered" || !strings.Contains(entry["panic"].(string), "nil map") {
+			t.Errorf("unexpected entry: %v", entry)
+		}
+		if caller, _ := entry["caller"].(string); !strings.Contains(caller, "crash_test.go:") {
+			t.Errorf("caller should be the panicking line, got %q", caller)
+		}
+		if stack, _ := entry["stacktrace"].(string); !strings.Contains(stack, "TestRecoverAndLog") {
+			t.Errorf("stack trace missing the panicking function: %q", stack)
+		}
+
+		// Whoever recovered the panic can keep logging, sinks included
+		logger.Error("after recovery")
+		logger.Close()
+		if records := readRecords(t, filepath.Join(tmpDir, "test.log")); len(records) != 2 {
+			t.Errorf("logger should stay open after RecoverRepanic, got %d records", len(records))
+		}
+		if stats := logger.SinkStats(); stats[0].Written != 2 || stats[0].Dropped != 0 {
+			t.Errorf("sink should stay open after RecoverRepanic: %+v", stats[0])
+		}
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		codes := stubExit(t)
+		tmpDir := t.TempDir()
+		logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer logger.RecoverAndLog(RecoverExit)
+			panic("worker failed")
+		}()
+		<-done
+
+		if len(*codes) != 1 || (*codes)[0] != 1 {
+			t.Fatalf("expected exit(1), got %v", *codes)
+		}
+		records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+		if len(records) != 1 || records[0]["level"] != "fatal" || records[0]["panic"] != "worker failed" {
+			t.Errorf("unexpected records: %v", records)
+		}
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		codes := stubExit(t)
+		tmpDir := t.TempDir()
+		logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		defer logger.Close()
+
+		func() {
+			defer logger.RecoverAndLog(RecoverExit)
+		}()
+		if len(*codes) != 0 {
+			t.Errorf("nothing to recover, but exit was called")
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// LogEntry is a decoded log record with the standard keys parsed once
+type LogEntry struct {
+	Time       time.Time
+	Level      LogLevel
+	Message    string
+	Caller     string
+	Stacktrace string
+	Logger     string
+
+	// Fields holds every other key of the record
+	Fields map[string]any
+}
+
+// NewLogEntry builds a LogEntry from a raw record as returned by Reader.Entry,
+// using DefaultSchema. Standard keys that are missing or have an unexpected
+// type are left in Fields, so no data is lost.
+func NewLogEntry(log map[string]interface{}) LogEntry {
+	return DefaultSchema().NewLogEntry(log)
+}
+
+// Map returns the entry as a raw record with the DefaultSchema keys, the
+// form FilterFunc works on
+func (e LogEntry) Map() map[string]interface{} {
+	return DefaultSchema().Map(e)
+}
+
+// MarshalJSON writes the entry as a flat JSON object with the same keys the
+// logger writes
+func (e LogEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Map())
+}
+
+// UnmarshalJSON reads a flat JSON log record
+func (e *LogEntry) UnmarshalJSON(data []byte) error {
+	var log map[string]interface{}
+	if err := json.Unmarshal(data, &log); err != nil {
+		return err
+	}
+	if log == nil {
+		return errors.New("log entry is not a JSON object")
+	}
+	*e = NewLogEntry(log)
+	return nil
+}
+
+// Field returns the field at path; dots reach into nested objects
+func (e LogEntry) Field(path string) (any, bool) {
+	return lookupField(e.Fields, path)
+}
+
+// String returns the string field at path
+func (e LogEntry) String(path string) (string, bool) {
+	return lookupString(e.Fields, path)
+}
+
+// Float64 returns the numeric field at path
+func (e LogEntry) Float64(path string) (float64, bool) {
+	value, ok := e.Field(path)
+	if !ok {
+		return 0, false
+	}
+	return toFloat(value)
+}
+
+// Int64 returns the numeric field at path, truncated to an integer
+func (e LogEntry) Int64(path string) (int64, bool) {
+	n, ok := e.Float64(path)
+	return int64(n), ok
+}
+
+// Bool returns the boolean field at path
+func (e LogEntry) Bool(path string) (bool, bool) {
+	value, ok := e.Field(path)
+	if !ok {
+		return false, false
+	}
+	b, ok := value.(bool)
+	return b, ok
+}
+
+// Duration returns the field at path as a duration. It accepts the string
+// form written by zap.Duration ("1.5s") and numbers of nanoseconds.
+func (e LogEntry) Duration(path string) (time.Duration, bool) {
+	value, ok := e.Field(path)
+	if !ok {
+		return 0, false
+	}
+	if s, ok := value.(string); ok {
+		d, err := time.ParseDuration(s)
+		return d, err == nil
+	}
+	n, ok := toFloat(value)
+	return time.Duration(n), ok
+}
+
+// LogEntry returns the entry read by the last successful call to Next in
+// its typed form, parsed with r.Schema
+func (r *Reader) LogEntry() LogEntry {
+	return r.Schema.NewLogEntry(r.entry)
+}
+
+// ScanLogEntries streams the typed entries of a plain or gzip log file that
+// match filter (nil = all) to fn. Returning ErrStopScan from fn stops
+// reading without error.
+func ScanLogEntries(filePath string, filter FilterFunc, fn func(entry LogEntry) error) error {
+	reader, err := OpenLogFile(filePath, filter)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for reader.Next() {
+		if err := fn(reader.LogEntry()); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return reader.Err()
+}
+
+// ReadLogEntries reads the typed entries of a plain or gzip log file that
+// match filter (nil = all)
+func ReadLogEntries(filePath string, filter FilterFunc) ([]LogEntry, error) {
+	var entries []LogEntry
+	err := ScanLogEntries(filePath, filter, func(entry LogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
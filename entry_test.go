@@ -0,0 +1,130 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestNewLogEntry(t *testing.T) {
+	var log map[string]interface{}
+	raw := `{"timestamp":"2025-12-02T15:59:57.317+0800","level":"ERROR","logger":"db",
+		"caller":"main.go:25","message":"query failed","stacktrace":"goroutine 1",
+		"attempt":3,"elapsed":"1.5s","retry":true,"http":{"status":504}}`
+	if err := json.Unmarshal([]byte(raw), &log); err != nil {
+		t.Fatalf("invalid test JSON: %v", err)
+	}
+
+	entry := NewLogEntry(log)
+
+	wantTime := time.Date(2025, 12, 2, 7, 59, 57, 317000000, time.UTC)
+	if !entry.Time.Equal(wantTime) {
+		t.Errorf("expected time %v, got %v", wantTime, entry.Time)
+	}
+	if entry.Level != ErrorLevel || entry.Message != "query failed" || entry.Logger != "db" ||
+		entry.Caller != "main.go:25" || entry.Stacktrace != "goroutine 1" {
+		t.Errorf("standard keys not parsed: %+v", entry)
+	}
+	if _, ok := entry.Fields["message"]; ok {
+		t.Error("standard keys should not be duplicated in Fields")
+	}
+
+	if n, ok := entry.Int64("attempt"); !ok || n != 3 {
+		t.Errorf("expected attempt 3, got %d", n)
+	}
+	if d, ok := entry.Duration("elapsed"); !ok || d != 1500*time.Millisecond {
+		t.Errorf("expected elapsed 1.5s, got %v", d)
+	}
+	if b, ok := entry.Bool("retry"); !ok || !b {
+		t.Error("expected retry true")
+	}
+	if n, ok := entry.Float64("http.status"); !ok || n != 504 {
+		t.Errorf("expected http.status 504, got %v", n)
+	}
+	if _, ok := entry.String("attempt"); ok {
+		t.Error("String should reject numeric fields")
+	}
+}
+
+func TestNewLogEntryKeepsUnparsedKeys(t *testing.T) {
+	entry := NewLogEntry(map[string]interface{}{
+		"timestamp": "yesterday",
+		"message":   float64(42),
+	})
+
+	if !entry.Time.IsZero() || entry.Message != "" {
+		t.Errorf("unexpected parsed values: %+v", entry)
+	}
+	if entry.Fields["timestamp"] != "yesterday" || entry.Fields["message"] != float64(42) {
+		t.Errorf("unparsed keys should stay in Fields: %v", entry.Fields)
+	}
+}
+
+func TestLogEntryJSONRoundTrip(t *testing.T) {
+	original := LogEntry{
+		Time:    time.Date(2025, 12, 2, 7, 59, 57, 317000000, time.UTC),
+		Level:   WarnLevel,
+		Message: "disk almost full",
+		Caller:  "disk.go:10",
+		Fields:  map[string]any{"free_mb": float64(120)},
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	var decoded LogEntry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if !decoded.Time.Equal(original.Time) || decoded.Level != original.Level ||
+		decoded.Message != original.Message || decoded.Caller != original.Caller ||
+		decoded.Fields["free_mb"] != float64(120) {
+		t.Errorf("round trip mismatch: %+v", decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`null`), &decoded); err == nil {
+		t.Error("expected error for a non-object")
+	}
+}
+
+func TestReadLogEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	before := time.Now().Add(-time.Second)
+	logger.Info("first", zap.Int("count", 1))
+	logger.Named("worker").Error("second", zap.Int("count", 2))
+	logger.Close()
+
+	entries, err := ReadLogEntries(filepath.Join(tmpDir, "test.log"), FilterByMinLevel(WarnLevel))
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != ErrorLevel || entry.Message != "second" || entry.Logger != "worker" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.Time.Before(before) || entry.Caller == "" {
+		t.Errorf("expected parsed time and caller: %+v", entry)
+	}
+
+	stop := errors.New("stop")
+	err = ScanLogEntries(filepath.Join(tmpDir, "test.log"), nil, func(LogEntry) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("expected callback error, got %v", err)
+	}
+}
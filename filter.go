@@ -0,0 +1,224 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FilterFunc is a function type for filtering logs
+type FilterFunc func(log map[string]interface{}) bool
+
+// FilterByLevel creates a filter for a specific log level
+func FilterByLevel(level string) FilterFunc {
+	return DefaultSchema().FilterByLevel(level)
+}
+
+// FilterByTimeRange creates a filter for logs within a time range
+func FilterByTimeRange(start, end time.Time) FilterFunc {
+	return DefaultSchema().FilterByTimeRange(start, end)
+}
+
+// parseTimestamp parses the time formats written by the encoder
+func parseTimestamp(ts string) (time.Time, error) {
+	// Try RFC3339Nano first (with colon in timezone)
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		// Try the format without colon in timezone: 2025-12-02T15:59:57.317+0800
+		t, err = time.Parse("2006-01-02T15:04:05.000-0700", ts)
+		if err != nil {
+			// Try with 3-digit milliseconds
+			t, err = time.Parse("2006-01-02T15:04:05.000Z0700", ts)
+		}
+	}
+	return t, err
+}
+
+// FilterByMinLevel creates a filter for logs at level or above, so
+// FilterByMinLevel(WarnLevel) keeps warn, error, fatal and panic entries.
+// An unknown level matches nothing.
+func FilterByMinLevel(level LogLevel) FilterFunc {
+	return DefaultSchema().FilterByMinLevel(level)
+}
+
+// And creates a filter that matches when all filters match
+func And(filters ...FilterFunc) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		for _, filter := range filters {
+			if !filter(log) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or creates a filter that matches when any filter matches
+func Or(filters ...FilterFunc) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		for _, filter := range filters {
+			if filter(log) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not creates a filter that matches when filter does not
+func Not(filter FilterFunc) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		return !filter(log)
+	}
+}
+
+// FieldExists creates a filter for logs that have the field at path.
+// Paths use dots to reach into nested objects, e.g. "http.request.method";
+// a top-level key that itself contains dots is matched first.
+func FieldExists(path string) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		_, ok := lookupField(log, path)
+		return ok
+	}
+}
+
+// FieldEquals creates a filter for logs whose field at path equals value.
+// Numbers compare by value regardless of their Go type.
+func FieldEquals(path string, value interface{}) FilterFunc {
+	want, wantNumeric := toFloat(value)
+	return func(log map[string]interface{}) bool {
+		got, ok := lookupField(log, path)
+		if !ok {
+			return false
+		}
+		if wantNumeric {
+			n, ok := toFloat(got)
+			return ok && n == want
+		}
+		return reflect.DeepEqual(got, value)
+	}
+}
+
+// FieldContains creates a filter for logs whose string field at path
+// contains substr
+func FieldContains(path, substr string) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		s, ok := lookupString(log, path)
+		return ok && strings.Contains(s, substr)
+	}
+}
+
+// FieldMatches creates a filter for logs whose string field at path
+// matches pattern
+func FieldMatches(path string, pattern *regexp.Regexp) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		s, ok := lookupString(log, path)
+		return ok && pattern.MatchString(s)
+	}
+}
+
+// FieldGreaterThan creates a filter for logs whose numeric field at path
+// is greater than value
+func FieldGreaterThan(path string, value float64) FilterFunc {
+	return compareField(path, func(n float64) bool { return n > value })
+}
+
+// FieldGreaterOrEqual creates a filter for logs whose numeric field at path
+// is greater than or equal to value
+func FieldGreaterOrEqual(path string, value float64) FilterFunc {
+	return compareField(path, func(n float64) bool { return n >= value })
+}
+
+// FieldLessThan creates a filter for logs whose numeric field at path is
+// less than value
+func FieldLessThan(path string, value float64) FilterFunc {
+	return compareField(path, func(n float64) bool { return n < value })
+}
+
+// FieldLessOrEqual creates a filter for logs whose numeric field at path
+// is less than or equal to value
+func FieldLessOrEqual(path string, value float64) FilterFunc {
+	return compareField(path, func(n float64) bool { return n <= value })
+}
+
+// compareField applies a numeric comparison to the field at path
+func compareField(path string, compare func(float64) bool) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		got, ok := lookupField(log, path)
+		if !ok {
+			return false
+		}
+		n, ok := toFloat(got)
+		return ok && compare(n)
+	}
+}
+
+// lookupField resolves a dotted path in a log entry. An exact key match
+// wins; otherwise each dot may separate a nested object from its field.
+func lookupField(log map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := log[path]; ok {
+		return value, true
+	}
+
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		nested, ok := log[path[:i]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := lookupField(nested, path[i+1:]); ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// lookupString resolves a dotted path to a string value
+func lookupString(log map[string]interface{}, path string) (string, bool) {
+	value, ok := lookupField(log, path)
+	if !ok {
+		return "", false
+	}
+	s, ok := value.(string)
+	return s, ok
+}
+
+// toFloat converts any Go numeric value to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
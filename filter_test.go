@@ -0,0 +1,91 @@
+package jsonlog
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilterByMinLevel(t *testing.T) {
+	filter := FilterByMinLevel(WarnLevel)
+
+	tests := map[string]bool{
+		"debug": false,
+		"info":  false,
+		"warn":  true,
+		"error": true,
+		"fatal": true,
+		"ERROR": true,
+		"bogus": false,
+	}
+	for level, want := range tests {
+		if got := filter(map[string]interface{}{"level": level}); got != want {
+			t.Errorf("level %q: expected %v, got %v", level, want, got)
+		}
+	}
+
+	if FilterByMinLevel("verbose")(map[string]interface{}{"level": "error"}) {
+		t.Error("an unknown minimum level should match nothing")
+	}
+}
+
+func TestFilterCombinators(t *testing.T) {
+	log := map[string]interface{}{"level": "error", "user_id": "u1"}
+
+	isError := FilterByLevel("error")
+	isU2 := FieldEquals("user_id", "u2")
+
+	if !And(isError, Not(isU2))(log) {
+		t.Error("And/Not: expected match")
+	}
+	if And(isError, isU2)(log) {
+		t.Error("And: expected no match")
+	}
+	if !Or(isU2, isError)(log) {
+		t.Error("Or: expected match")
+	}
+	if Or()(log) || !And()(log) {
+		t.Error("empty Or should match nothing and empty And everything")
+	}
+}
+
+func TestFieldPredicates(t *testing.T) {
+	log := map[string]interface{}{
+		"message":     "payment failed for order 42",
+		"duration_ms": float64(750),
+		"log.origin":  "flat key with dots",
+		"http": map[string]interface{}{
+			"status": float64(502),
+			"request": map[string]interface{}{
+				"method": "POST",
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		filter FilterFunc
+		want   bool
+	}{
+		{"equals string", FieldEquals("http.request.method", "POST"), true},
+		{"equals int", FieldEquals("http.status", 502), true},
+		{"equals mismatch", FieldEquals("http.status", "502"), false},
+		{"contains", FieldContains("message", "order 42"), true},
+		{"contains non-string", FieldContains("duration_ms", "750"), false},
+		{"matches", FieldMatches("message", regexp.MustCompile(`order \d+`)), true},
+		{"greater than", FieldGreaterThan("duration_ms", 500), true},
+		{"greater or equal", FieldGreaterOrEqual("duration_ms", 750), true},
+		{"less than", FieldLessThan("duration_ms", 750), false},
+		{"less or equal", FieldLessOrEqual("http.status", 502), true},
+		{"exists nested", FieldExists("http.request.method"), true},
+		{"exists flat dotted key", FieldExists("log.origin"), true},
+		{"missing", FieldExists("http.response"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter(log); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
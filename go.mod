@@ -2,9 +2,9 @@ module github.com/gusdeyw/jsonlog-go
 
 go 1.21
 
-require go.uber.org/zap v1.26.0
-
 require (
-	go.uber.org/multierr v1.10.0 // indirect
-	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+	go.uber.org/zap v1.26.0
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
+
+require go.uber.org/multierr v1.10.0 // indirect
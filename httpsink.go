@@ -0,0 +1,654 @@
+package jsonlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// HTTPFormat selects the request body layout of an HTTPSink
+type HTTPFormat string
+
+const (
+	NDJSONFormat            HTTPFormat = "ndjson"     // one entry per line
+	JSONArrayFormat         HTTPFormat = "json_array" // [entry, entry, ...]
+	LokiFormat              HTTPFormat = "loki"       // Loki push API streams
+	ElasticsearchBulkFormat HTTPFormat = "es_bulk"    // Elasticsearch _bulk create actions
+)
+
+// HTTPSinkConfig holds the settings of an HTTPSink
+type HTTPSinkConfig struct {
+	// URL is the endpoint batches are POSTed to
+	URL string
+
+	// Format is the body layout (empty = NDJSONFormat)
+	Format HTTPFormat
+
+	// Headers are added to every request, e.g. Authorization
+	Headers map[string]string
+
+	// Gzip compresses request bodies
+	Gzip bool
+
+	// BatchSize is the most entries per request (0 = 500)
+	BatchSize int
+
+	// BatchInterval is the longest an entry waits before it is sent
+	// (0 = 1 second)
+	BatchInterval time.Duration
+
+	// Timeout bounds each request (0 = 10 seconds)
+	Timeout time.Duration
+
+	// MinBackoff and MaxBackoff bound the wait after a failed request,
+	// which doubles on every failure (0 = 500ms and 1 minute)
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// SpoolDir keeps batches that could not be sent on disk, so they
+	// survive collector outages and restarts. Relative paths are under
+	// Config.LogPath (empty = keep up to 16 batches in memory).
+	SpoolDir string
+
+	// MaxSpoolBytes bounds the spool; the oldest batches are dropped
+	// first (0 = 100 MB)
+	MaxSpoolBytes int64
+
+	// Labels are the Loki stream labels; a level label is added
+	Labels map[string]string
+
+	// Index is the Elasticsearch index of the bulk actions (empty = the
+	// index in URL)
+	Index string
+}
+
+const (
+	defaultHTTPBatchSize     = 500
+	defaultHTTPBatchInterval = time.Second
+	defaultHTTPTimeout       = 10 * time.Second
+	defaultMinBackoff        = 500 * time.Millisecond
+	defaultMaxBackoff        = time.Minute
+	defaultMaxSpoolBytes     = 100 * megabyte
+
+	// maxMemoryBatches bounds the undelivered batches kept without a spool
+	maxMemoryBatches = 16
+
+	spoolSuffix = ".batch"
+)
+
+// HTTPSinkStats reports the delivery state of an HTTPSink
+type HTTPSinkStats struct {
+	Sent      uint64 // entries accepted by the endpoint
+	Dropped   uint64 // entries given up on: rejected, or pushed out of a full buffer or spool
+	Pending   int    // entries waiting to be sent, in memory or spooled
+	LastError error
+}
+
+// HTTPSink ships entries to an HTTP endpoint in batches. Writes only queue
+// the entry; a background loop sends batches with exponential backoff and
+// keeps undelivered ones in memory or in SpoolDir.
+type HTTPSink struct {
+	config HTTPSinkConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	batch       []httpEntry
+	memory      []pendingBatch
+	stats       HTTPSinkStats
+	backoff     time.Duration
+	nextAttempt time.Time
+	closed      bool
+
+	full     chan struct{}
+	flushReq chan chan error
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// httpEntry is one queued entry
+type httpEntry struct {
+	time  time.Time
+	level zapcore.Level
+	line  []byte // encoded entry without the line ending
+}
+
+// pendingBatch is a formatted body waiting to be sent
+type pendingBatch struct {
+	body  []byte
+	count int
+	path  string // spool file, empty for memory batches
+}
+
+// errPermanent marks responses that retrying cannot fix
+type errPermanent struct{ err error }
+
+func (e errPermanent) Error() string { return e.err.Error() }
+func (e errPermanent) Unwrap() error { return e.err }
+
+// NewHTTPSink validates config, picks up batches left in SpoolDir and starts
+// the delivery loop
+func NewHTTPSink(config HTTPSinkConfig) (*HTTPSink, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q", config.URL)
+	}
+
+	switch config.Format {
+	case "":
+		config.Format = NDJSONFormat
+	case NDJSONFormat, JSONArrayFormat, LokiFormat, ElasticsearchBulkFormat:
+	default:
+		return nil, fmt.Errorf("unknown Format %q", config.Format)
+	}
+
+	for name, value := range map[string]int64{
+		"BatchSize":     int64(config.BatchSize),
+		"BatchInterval": int64(config.BatchInterval),
+		"Timeout":       int64(config.Timeout),
+		"MinBackoff":    int64(config.MinBackoff),
+		"MaxBackoff":    int64(config.MaxBackoff),
+		"MaxSpoolBytes": config.MaxSpoolBytes,
+	} {
+		if value < 0 {
+			return nil, fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	setDefault := func(value *time.Duration, fallback time.Duration) {
+		if *value == 0 {
+			*value = fallback
+		}
+	}
+	setDefault(&config.BatchInterval, defaultHTTPBatchInterval)
+	setDefault(&config.Timeout, defaultHTTPTimeout)
+	setDefault(&config.MinBackoff, defaultMinBackoff)
+	setDefault(&config.MaxBackoff, defaultMaxBackoff)
+	if config.BatchSize == 0 {
+		config.BatchSize = defaultHTTPBatchSize
+	}
+	if config.MaxSpoolBytes == 0 {
+		config.MaxSpoolBytes = defaultMaxSpoolBytes
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		return nil, fmt.Errorf("MaxBackoff %s is less than MinBackoff %s", config.MaxBackoff, config.MinBackoff)
+	}
+
+	if config.SpoolDir != "" {
+		if err := os.MkdirAll(config.SpoolDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create spool directory: %w", err)
+		}
+	}
+
+	s := &HTTPSink{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		full:     make(chan struct{}, 1),
+		flushReq: make(chan chan error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func newHTTPSinkFromURL(u *url.URL) (Sink, error) {
+	return NewHTTPSink(HTTPSinkConfig{URL: u.String()})
+}
+
+// WriteEntry queues the entry for the next batch
+func (s *HTTPSink) WriteEntry(ent zapcore.Entry, p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("http sink is closed")
+	}
+
+	// Bound memory while the loop is busy with a slow endpoint
+	if limit := s.config.BatchSize * maxMemoryBatches; len(s.batch) >= limit {
+		s.batch = s.batch[1:]
+		s.stats.Dropped++
+	}
+
+	line := bytes.TrimRight(p, "\n")
+	s.batch = append(s.batch, httpEntry{time: ent.Time, level: ent.Level, line: append([]byte(nil), line...)})
+	if len(s.batch) >= s.config.BatchSize {
+		select {
+		case s.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Sync sends what is queued. During a backoff it returns the last error at
+// once: Sync runs on the logging path for DPanic and above, and must not
+// wait on an endpoint that is known to be failing. Close tries regardless.
+func (s *HTTPSink) Sync() error {
+	reply := make(chan error)
+	select {
+	case s.flushReq <- reply:
+		return <-reply
+	case <-s.done:
+		return nil
+	}
+}
+
+// Close stops the loop after a last attempt to send. Batches that remain
+// stay in SpoolDir for the next start; without a spool they are lost and
+// reported in the error.
+func (s *HTTPSink) Close() error {
+	var err error
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+
+		close(s.stop)
+		<-s.done
+		err = s.flush(true)
+		s.client.CloseIdleConnections()
+
+		if err != nil && s.config.SpoolDir != "" {
+			err = nil
+		} else if err != nil {
+			err = fmt.Errorf("%d entries not delivered: %w", s.Stats().Pending, err)
+		}
+	})
+	return err
+}
+
+// Stats returns the delivery counters
+func (s *HTTPSink) Stats() HTTPSinkStats {
+	s.mu.Lock()
+	stats := s.stats
+	stats.Pending = len(s.batch)
+	for _, pending := range s.memory {
+		stats.Pending += pending.count
+	}
+	s.mu.Unlock()
+
+	for _, pending := range s.spooled() {
+		stats.Pending += pending.count
+	}
+	return stats
+}
+
+func (s *HTTPSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.BatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(false)
+		case <-s.full:
+			s.flush(false)
+		case reply := <-s.flushReq:
+			reply <- s.flush(false)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// flush sends pending batches oldest first, then the queued entries. Force
+// ignores the backoff. It returns the last error if anything is left.
+func (s *HTTPSink) flush(force bool) error {
+	var lastErr error
+	blocked := false
+
+	for _, pending := range s.pending() {
+		body := pending.body
+		if pending.path != "" {
+			var err error
+			if body, err = os.ReadFile(pending.path); err != nil {
+				s.drop(pending.count, err)
+				s.removePending(pending)
+				continue
+			}
+		}
+		if err := s.deliver(body, pending.count, force); err != nil {
+			if !errors.As(err, new(errPermanent)) {
+				lastErr, blocked = err, true
+				break
+			}
+		}
+		s.removePending(pending)
+	}
+
+	// During a backoff new entries stay queued, where WriteEntry bounds
+	// them; parking them would make a small batch on every tick
+	s.mu.Lock()
+	if !force && (blocked || time.Now().Before(s.nextAttempt)) && len(s.batch) > 0 {
+		if lastErr == nil {
+			lastErr = s.stats.LastError
+		}
+		s.mu.Unlock()
+		if lastErr == nil {
+			lastErr = errors.New("waiting to retry")
+		}
+		return lastErr
+	}
+	entries := s.batch
+	s.batch = nil
+	s.mu.Unlock()
+
+	for start := 0; start < len(entries); start += s.config.BatchSize {
+		end := start + s.config.BatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		chunk := entries[start:end]
+		body, err := s.encode(chunk)
+		if err != nil {
+			s.drop(len(chunk), err)
+			continue
+		}
+
+		// Keep order behind batches that are still waiting
+		if !blocked {
+			err = s.deliver(body, len(chunk), force)
+			if err == nil || errors.As(err, new(errPermanent)) {
+				continue
+			}
+			lastErr, blocked = err, true
+		}
+		s.park(body, len(chunk))
+	}
+
+	return lastErr
+}
+
+// deliver sends one body, honoring and updating the backoff
+func (s *HTTPSink) deliver(body []byte, count int, force bool) error {
+	s.mu.Lock()
+	if !force && time.Now().Before(s.nextAttempt) {
+		err := s.stats.LastError
+		s.mu.Unlock()
+		if err == nil {
+			err = errors.New("waiting to retry")
+		}
+		return err
+	}
+	s.mu.Unlock()
+
+	err := s.send(body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case err == nil:
+		s.stats.Sent += uint64(count)
+		s.backoff = 0
+		s.nextAttempt = time.Time{}
+	case errors.As(err, new(errPermanent)):
+		s.stats.Dropped += uint64(count)
+		s.stats.LastError = err
+	default:
+		s.stats.LastError = err
+		s.backoff *= 2
+		if s.backoff < s.config.MinBackoff {
+			s.backoff = s.config.MinBackoff
+		}
+		if s.backoff > s.config.MaxBackoff {
+			s.backoff = s.config.MaxBackoff
+		}
+		s.nextAttempt = time.Now().Add(s.backoff)
+	}
+	return err
+}
+
+// send POSTs one body
+func (s *HTTPSink) send(body []byte) error {
+	var reader io.Reader = bytes.NewReader(body)
+	if s.config.Gzip {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write(body)
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to compress batch: %w", err)
+		}
+		reader = &compressed
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, reader)
+	if err != nil {
+		return errPermanent{err}
+	}
+	req.Header.Set("Content-Type", s.contentType())
+	if s.config.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for name, value := range s.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500:
+		return fmt.Errorf("POST %s: %s", s.config.URL, resp.Status)
+	default:
+		return errPermanent{fmt.Errorf("POST %s: %s", s.config.URL, resp.Status)}
+	}
+}
+
+func (s *HTTPSink) contentType() string {
+	switch s.config.Format {
+	case NDJSONFormat, ElasticsearchBulkFormat:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// encode builds the request body for a batch
+func (s *HTTPSink) encode(entries []httpEntry) ([]byte, error) {
+	var body bytes.Buffer
+
+	switch s.config.Format {
+	case JSONArrayFormat:
+		body.WriteByte('[')
+		for i, entry := range entries {
+			if i > 0 {
+				body.WriteByte(',')
+			}
+			body.Write(entry.line)
+		}
+		body.WriteByte(']')
+
+	case ElasticsearchBulkFormat:
+		action := []byte(`{"create":{}}`)
+		if s.config.Index != "" {
+			var err error
+			action, err = json.Marshal(map[string]map[string]string{"create": {"_index": s.config.Index}})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, entry := range entries {
+			body.Write(action)
+			body.WriteByte('\n')
+			body.Write(entry.line)
+			body.WriteByte('\n')
+		}
+
+	case LokiFormat:
+		type lokiStream struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		}
+		streams := make(map[zapcore.Level]*lokiStream)
+		var levels []zapcore.Level
+		for _, entry := range entries {
+			stream, ok := streams[entry.level]
+			if !ok {
+				labels := map[string]string{"level": entry.level.String()}
+				for name, value := range s.config.Labels {
+					labels[name] = value
+				}
+				stream = &lokiStream{Stream: labels}
+				streams[entry.level] = stream
+				levels = append(levels, entry.level)
+			}
+			stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.time.UnixNano(), 10), string(entry.line)})
+		}
+		push := struct {
+			Streams []*lokiStream `json:"streams"`
+		}{}
+		for _, level := range levels {
+			push.Streams = append(push.Streams, streams[level])
+		}
+		return json.Marshal(push)
+
+	default: // NDJSONFormat
+		for _, entry := range entries {
+			body.Write(entry.line)
+			body.WriteByte('\n')
+		}
+	}
+
+	return body.Bytes(), nil
+}
+
+// park keeps a batch that could not be sent, in the spool or in memory
+func (s *HTTPSink) park(body []byte, count int) {
+	if s.config.SpoolDir != "" {
+		if err := s.spool(body, count); err != nil {
+			s.drop(count, err)
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.memory) >= maxMemoryBatches {
+		s.stats.Dropped += uint64(s.memory[0].count)
+		s.memory = s.memory[1:]
+	}
+	s.memory = append(s.memory, pendingBatch{body: body, count: count})
+}
+
+// pending returns the waiting batches, oldest first
+func (s *HTTPSink) pending() []pendingBatch {
+	if s.config.SpoolDir != "" {
+		return s.spooled()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]pendingBatch(nil), s.memory...)
+}
+
+// removePending forgets a batch returned by pending
+func (s *HTTPSink) removePending(batch pendingBatch) {
+	if batch.path != "" {
+		os.Remove(batch.path)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.memory) > 0 {
+		s.memory = s.memory[1:]
+	}
+}
+
+func (s *HTTPSink) drop(count int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Dropped += uint64(count)
+	s.stats.LastError = err
+}
+
+// spool writes a batch to SpoolDir as <unix nanos>-<entries>.batch. The
+// file appears atomically, so a crash never leaves half a batch behind.
+func (s *HTTPSink) spool(body []byte, count int) error {
+	name := fmt.Sprintf("%020d-%d%s", time.Now().UnixNano(), count, spoolSuffix)
+	tmp, err := os.CreateTemp(s.config.SpoolDir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to spool batch: %w", err)
+	}
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to spool batch: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to spool batch: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.config.SpoolDir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to spool batch: %w", err)
+	}
+
+	s.trimSpool()
+	return nil
+}
+
+// trimSpool drops the oldest batches while the spool is over MaxSpoolBytes
+func (s *HTTPSink) trimSpool() {
+	batches := s.spooled()
+	var total int64
+	sizes := make([]int64, len(batches))
+	for i, batch := range batches {
+		if info, err := os.Stat(batch.path); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+
+	for i := 0; i < len(batches)-1 && total > s.config.MaxSpoolBytes; i++ {
+		if os.Remove(batches[i].path) == nil {
+			total -= sizes[i]
+			s.drop(batches[i].count, errors.New("spool is full"))
+		}
+	}
+}
+
+// spooled lists the spool files oldest first; flush reads their bodies
+func (s *HTTPSink) spooled() []pendingBatch {
+	if s.config.SpoolDir == "" {
+		return nil
+	}
+	dirEntries, err := os.ReadDir(s.config.SpoolDir)
+	if err != nil {
+		return nil
+	}
+
+	var batches []pendingBatch
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, spoolSuffix) {
+			continue
+		}
+		_, countText, _ := strings.Cut(strings.TrimSuffix(name, spoolSuffix), "-")
+		count, _ := strconv.Atoi(countText)
+		batches = append(batches, pendingBatch{count: count, path: filepath.Join(s.config.SpoolDir, name)})
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].path < batches[j].path })
+	return batches
+}
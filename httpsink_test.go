@@ -0,0 +1,397 @@
+package jsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// collector is an httptest handler that records request bodies and answers
+// with status
+type collector struct {
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	requests int
+	status   atomic.Int32
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{}
+	c.status.Store(http.StatusOK)
+	server := httptest.NewServer(c)
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reader = gz
+	}
+	body, _ := io.ReadAll(reader)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	status := int(c.status.Load())
+	if status == http.StatusOK {
+		c.bodies = append(c.bodies, string(body))
+		c.headers = append(c.headers, r.Header.Clone())
+	}
+	w.WriteHeader(status)
+}
+
+func (c *collector) received() ([]string, []http.Header, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.bodies...), c.headers, c.requests
+}
+
+// entryAt builds an info entry for writing to a sink directly
+func entryAt(message string) zapcore.Entry {
+	return zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: message}
+}
+
+func TestHTTPSinkFormats(t *testing.T) {
+	tests := []struct {
+		format HTTPFormat
+		gzip   bool
+		check  func(t *testing.T, body string)
+	}{
+		{NDJSONFormat, false, func(t *testing.T, body string) {
+			lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+			if len(lines) != 2 || !strings.Contains(lines[0], `"message":"first"`) {
+				t.Errorf("unexpected ndjson body: %q", body)
+			}
+		}},
+		{JSONArrayFormat, true, func(t *testing.T, body string) {
+			var entries []map[string]interface{}
+			if err := json.Unmarshal([]byte(body), &entries); err != nil || len(entries) != 2 {
+				t.Errorf("unexpected json array body %q: %v", body, err)
+			}
+		}},
+		{ElasticsearchBulkFormat, false, func(t *testing.T, body string) {
+			lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+			if len(lines) != 4 || lines[0] != `{"create":{"_index":"logs-app"}}` || !strings.Contains(lines[1], `"first"`) {
+				t.Errorf("unexpected bulk body: %q", body)
+			}
+		}},
+		{LokiFormat, true, func(t *testing.T, body string) {
+			var push struct {
+				Streams []struct {
+					Stream map[string]string `json:"stream"`
+					Values [][2]string       `json:"values"`
+				} `json:"streams"`
+			}
+			if err := json.Unmarshal([]byte(body), &push); err != nil {
+				t.Fatalf("invalid loki body %q: %v", body, err)
+			}
+			if len(push.Streams) != 2 {
+				t.Fatalf("expected a stream per level, got %d", len(push.Streams))
+			}
+			stream := push.Streams[0]
+			if stream.Stream["job"] != "api" || stream.Stream["level"] != "info" || len(stream.Values) != 1 {
+				t.Errorf("unexpected stream: %+v", stream)
+			}
+			if !strings.Contains(stream.Values[0][1], `"first"`) || len(stream.Values[0][0]) < 19 {
+				t.Errorf("unexpected values: %v", stream.Values)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			c, server := newCollector(t)
+
+			logger, err := NewLogger(Config{
+				LogPath: t.TempDir(),
+				Sinks: []SinkConfig{{HTTP: &HTTPSinkConfig{
+					URL:       server.URL,
+					Format:    tt.format,
+					Gzip:      tt.gzip,
+					BatchSize: 2,
+					Headers:   map[string]string{"Authorization": "Bearer t"},
+					Labels:    map[string]string{"job": "api"},
+					Index:     "logs-app",
+				}}},
+			})
+			if err != nil {
+				t.Fatalf("failed to create logger: %v", err)
+			}
+			logger.Info("first")
+			logger.Warn("second")
+			logger.Info("third")
+			if err := logger.Close(); err != nil {
+				t.Fatalf("failed to close logger: %v", err)
+			}
+
+			bodies, headers, _ := c.received()
+			if len(bodies) != 2 {
+				t.Fatalf("expected 2 batches, got %d", len(bodies))
+			}
+			tt.check(t, bodies[0])
+			if headers[0].Get("Authorization") != "Bearer t" {
+				t.Errorf("missing header: %v", headers[0])
+			}
+			if got := headers[0].Get("Content-Encoding") == "gzip"; got != tt.gzip {
+				t.Errorf("gzip = %v, want %v", got, tt.gzip)
+			}
+
+			stats := logger.SinkStats()[0]
+			if stats.Written != 3 || stats.Pending != 0 || stats.Dropped != 0 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+		})
+	}
+}
+
+func TestHTTPSinkSpoolSurvivesRestart(t *testing.T) {
+	c, server := newCollector(t)
+	c.status.Store(http.StatusServiceUnavailable)
+	tmpDir := t.TempDir()
+
+	config := Config{
+		LogPath: tmpDir,
+		Sinks: []SinkConfig{{HTTP: &HTTPSinkConfig{
+			URL:        server.URL,
+			SpoolDir:   "spool",
+			MinBackoff: time.Millisecond,
+		}}},
+	}
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	for _, message := range []string{"one", "two", "three"} {
+		logger.Info(message)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("spooled entries should not fail Close: %v", err)
+	}
+
+	spooled, _ := filepath.Glob(filepath.Join(tmpDir, "spool", "*.batch"))
+	if len(spooled) == 0 {
+		t.Fatal("expected spooled batches")
+	}
+
+	// The collector is back; a new process sends the spool first
+	c.status.Store(http.StatusOK)
+	logger, err = NewLogger(config)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Info("four")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	bodies, _, _ := c.received()
+	var messages []string
+	for _, body := range bodies {
+		scanner := bufio.NewScanner(strings.NewReader(body))
+		for scanner.Scan() {
+			var record map[string]interface{}
+			json.Unmarshal(scanner.Bytes(), &record)
+			messages = append(messages, record["message"].(string))
+		}
+	}
+	if strings.Join(messages, ",") != "one,two,three,four" {
+		t.Errorf("unexpected delivery order: %v", messages)
+	}
+	if spooled, _ := filepath.Glob(filepath.Join(tmpDir, "spool", "*.batch")); len(spooled) != 0 {
+		t.Errorf("spool should be empty, got %v", spooled)
+	}
+}
+
+func TestHTTPSinkBackoff(t *testing.T) {
+	c, server := newCollector(t)
+	c.status.Store(http.StatusBadGateway)
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{URL: server.URL, MinBackoff: time.Hour, MaxBackoff: time.Hour, BatchInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	write := func(message string) {
+		if err := sink.WriteEntry(entryAt(message), []byte(`{"message":"`+message+`"}`+"\n")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+
+	write("a")
+	if err := sink.Sync(); err == nil {
+		t.Fatal("expected a delivery error")
+	}
+	_, _, requests := c.received()
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+
+	// Within the backoff neither the loop nor Sync calls the endpoint
+	c.status.Store(http.StatusOK)
+	write("b")
+	if err := sink.flush(false); err == nil {
+		t.Error("expected the backoff to hold delivery")
+	}
+	if err := sink.Sync(); err == nil {
+		t.Error("expected Sync to report the backoff")
+	}
+	if _, _, got := c.received(); got != requests {
+		t.Errorf("request made during backoff")
+	}
+	if stats := sink.Stats(); stats.Pending != 2 || stats.LastError == nil {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Close tries regardless and keeps the order
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	bodies, _, _ := c.received()
+	if strings.Join(bodies, "") != "{\"message\":\"a\"}\n{\"message\":\"b\"}\n" {
+		t.Errorf("unexpected bodies: %q", bodies)
+	}
+	if stats := sink.Stats(); stats.Sent != 2 || stats.Pending != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHTTPSinkOutageKeepsEntries(t *testing.T) {
+	for _, spool := range []bool{false, true} {
+		t.Run(fmt.Sprintf("spool=%v", spool), func(t *testing.T) {
+			c, server := newCollector(t)
+			c.status.Store(http.StatusServiceUnavailable)
+
+			config := HTTPSinkConfig{
+				URL: server.URL, BatchSize: 10, BatchInterval: 5 * time.Millisecond,
+				MinBackoff: time.Hour, MaxBackoff: time.Hour,
+			}
+			if spool {
+				config.SpoolDir = filepath.Join(t.TempDir(), "spool")
+			}
+			sink, err := NewHTTPSink(config)
+			if err != nil {
+				t.Fatalf("failed to create sink: %v", err)
+			}
+			defer sink.Close()
+
+			// Well below the capacity of 16 batches of 10, spread over many
+			// ticks of the delivery loop
+			for i := 0; i < 100; i++ {
+				sink.WriteEntry(entryAt("during outage"), []byte(`{"message":"during outage"}`))
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			if stats := sink.Stats(); stats.Dropped != 0 || stats.Pending != 100 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+			if _, _, requests := c.received(); requests != 1 {
+				t.Errorf("expected a single attempt during the backoff, got %d", requests)
+			}
+			if spool {
+				if files, _ := filepath.Glob(filepath.Join(config.SpoolDir, "*.batch")); len(files) > 1 {
+					t.Errorf("the backoff should not spool a batch per tick, got %d files", len(files))
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPSinkRejectedBatch(t *testing.T) {
+	c, server := newCollector(t)
+	c.status.Store(http.StatusBadRequest)
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{URL: server.URL, BatchInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	sink.WriteEntry(entryAt("bad"), []byte(`{"message":"bad"}`))
+	sink.Sync()
+	sink.Sync()
+
+	if _, _, requests := c.received(); requests != 1 {
+		t.Errorf("a rejected batch should not be retried, got %d requests", requests)
+	}
+	if stats := sink.Stats(); stats.Dropped != 1 || stats.Pending != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("nothing is pending, Close should succeed: %v", err)
+	}
+}
+
+func TestHTTPSinkSpoolLimit(t *testing.T) {
+	c, server := newCollector(t)
+	c.status.Store(http.StatusServiceUnavailable)
+	spoolDir := filepath.Join(t.TempDir(), "spool")
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{
+		URL: server.URL, SpoolDir: spoolDir, MaxSpoolBytes: 40, BatchSize: 1,
+		BatchInterval: time.Hour, MinBackoff: time.Hour, MaxBackoff: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	for _, message := range []string{"first", "second", "third"} {
+		sink.WriteEntry(entryAt(message), []byte(`{"message":"`+message+`"}`))
+	}
+	sink.Close()
+
+	files, _ := filepath.Glob(filepath.Join(spoolDir, "*.batch"))
+	var kept bytes.Buffer
+	for _, file := range files {
+		content, _ := os.ReadFile(file)
+		kept.Write(content)
+	}
+	if strings.Contains(kept.String(), "first") || !strings.Contains(kept.String(), "third") {
+		t.Errorf("oldest batches should be dropped first, kept %q", kept.String())
+	}
+	if stats := sink.Stats(); stats.Dropped == 0 {
+		t.Errorf("expected dropped entries: %+v", stats)
+	}
+}
+
+func TestHTTPSinkValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config HTTPSinkConfig
+	}{
+		{"missing URL", HTTPSinkConfig{}},
+		{"not http", HTTPSinkConfig{URL: "ftp://example.com"}},
+		{"unknown format", HTTPSinkConfig{URL: "http://example.com", Format: "xml"}},
+		{"negative batch size", HTTPSinkConfig{URL: "http://example.com", BatchSize: -1}},
+		{"backoff range", HTTPSinkConfig{URL: "http://example.com", MinBackoff: time.Minute, MaxBackoff: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			if _, err := NewLogger(Config{LogPath: t.TempDir(), Sinks: []SinkConfig{{HTTP: &config}}}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
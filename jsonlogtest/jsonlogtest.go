@@ -0,0 +1,201 @@
+// Package jsonlogtest provides a jsonlog.Logger that keeps its entries in
+// memory, with assertions for unit tests. Production code keeps taking a
+// *jsonlog.Logger; tests pass the embedded one:
+//
+//	logger := jsonlogtest.New(t)
+//	service := NewService(logger.Logger)
+//	service.Start()
+//	logger.RequireLogged(t, jsonlog.InfoLevel, "service started", zap.Int("port", 8080))
+//
+// The captured entries are printed with t.Log when the test fails.
+package jsonlogtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gusdeyw/jsonlog-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is a jsonlog.Logger whose entries are kept in memory
+type Logger struct {
+	*jsonlog.Logger
+
+	mu      sync.Mutex
+	lines   []string
+	entries []map[string]interface{}
+}
+
+// New returns a Logger at debug level with the default schema. It is closed
+// when the test ends.
+func New(t testing.TB) *Logger {
+	return NewWithConfig(t, jsonlog.Config{Level: jsonlog.DebugLevel})
+}
+
+// NewWithConfig returns a Logger built from config, with the log file
+// turned off and an in-memory sink added. Schema, presets, redaction,
+// sampling and the other options apply as in production. A config that
+// NewLogger rejects fails the test.
+func NewWithConfig(t testing.TB, config jsonlog.Config) *Logger {
+	t.Helper()
+
+	l := &Logger{}
+	config.DisableFile = true
+	config.Sinks = append(config.Sinks[:len(config.Sinks):len(config.Sinks)], jsonlog.SinkConfig{
+		Name: "jsonlogtest",
+		Sink: memorySink{l},
+	})
+
+	logger, err := jsonlog.NewLogger(config)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	l.Logger = logger
+
+	t.Cleanup(func() {
+		logger.Close()
+		if t.Failed() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			for _, line := range l.lines {
+				t.Log(line)
+			}
+		}
+	})
+	return l
+}
+
+// Entries returns the captured entries in the order they were logged,
+// decoded as Reader.Entry would decode them from a log file
+func (l *Logger) Entries() []map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]map[string]interface{}, len(l.entries))
+	copy(entries, l.entries)
+	return entries
+}
+
+// FilterEntries returns the captured entries that match filter
+func (l *Logger) FilterEntries(filter jsonlog.FilterFunc) []map[string]interface{} {
+	var matched []map[string]interface{}
+	for _, entry := range l.Entries() {
+		if filter(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// Reset drops the captured entries
+func (l *Logger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lines = nil
+	l.entries = nil
+}
+
+// RequireLogged fails the test unless an entry was logged at level with
+// msg and every one of fields. Other fields of the entry are ignored, and
+// numbers compare by value.
+func (l *Logger) RequireLogged(t testing.TB, level jsonlog.LogLevel, msg string, fields ...zap.Field) {
+	t.Helper()
+
+	schema := l.Schema()
+	expected, err := encodeFields(fields, schema)
+	if err != nil {
+		t.Fatalf("RequireLogged: %v", err)
+	}
+	filters := make([]jsonlog.FilterFunc, 0, len(expected))
+	for key, value := range expected {
+		filters = append(filters, fieldEquals(key, value))
+	}
+	match := jsonlog.And(filters...)
+
+	for _, entry := range l.Entries() {
+		logEntry := schema.NewLogEntry(entry)
+		if logEntry.Level == level && logEntry.Message == msg && match(logEntry.Fields) {
+			return
+		}
+	}
+
+	want := fmt.Sprintf("%s %q", level, msg)
+	if len(expected) > 0 {
+		data, _ := json.Marshal(expected)
+		want += " " + string(data)
+	}
+	l.mu.Lock()
+	captured := strings.Join(l.lines, "\n")
+	l.mu.Unlock()
+	if captured == "" {
+		captured = "(none)"
+	}
+	t.Fatalf("no entry matched %s; captured entries:\n%s", want, captured)
+}
+
+// fieldEquals matches a top-level key, which unlike a FieldEquals path may
+// contain dots
+func fieldEquals(key string, value interface{}) jsonlog.FilterFunc {
+	equals := jsonlog.FieldEquals("value", value)
+	return func(log map[string]interface{}) bool {
+		got, ok := log[key]
+		return ok && equals(map[string]interface{}{"value": got})
+	}
+}
+
+// encodeFields turns fields into the values a decoded entry holds for them,
+// encoding durations and times as the logger does
+func encodeFields(fields []zap.Field, schema jsonlog.Schema) (map[string]interface{}, error) {
+	encoderConfig := zapcore.EncoderConfig{
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+	}
+	switch schema.TimeEncoding {
+	case jsonlog.RFC3339NanoTimeEncoding:
+		encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	case jsonlog.EpochMillisTimeEncoding:
+		encoderConfig.EncodeTime = zapcore.EpochMillisTimeEncoder
+	case jsonlog.EpochNanosTimeEncoding:
+		encoderConfig.EncodeTime = zapcore.EpochNanosTimeEncoder
+	}
+
+	buf, err := zapcore.NewJSONEncoder(encoderConfig).EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode expected fields: %w", err)
+	}
+	defer buf.Free()
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode expected fields: %w", err)
+	}
+	return decoded, nil
+}
+
+// memorySink records the encoded entries of a Logger
+type memorySink struct {
+	logger *Logger
+}
+
+func (s memorySink) WriteEntry(_ zapcore.Entry, p []byte) error {
+	line := strings.TrimSuffix(string(p), "\n")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(p, &entry); err != nil {
+		return fmt.Errorf("failed to decode entry: %w", err)
+	}
+
+	s.logger.mu.Lock()
+	defer s.logger.mu.Unlock()
+	s.logger.lines = append(s.logger.lines, line)
+	s.logger.entries = append(s.logger.entries, entry)
+	return nil
+}
+
+func (memorySink) Sync() error  { return nil }
+func (memorySink) Close() error { return nil }
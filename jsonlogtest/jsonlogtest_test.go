@@ -0,0 +1,150 @@
+package jsonlogtest
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gusdeyw/jsonlog-go"
+	"go.uber.org/zap"
+)
+
+// fakeTB records failures, logs and cleanups instead of acting on them
+type fakeTB struct {
+	testing.TB
+	failed   bool
+	fatals   []string
+	logs     []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper()               {}
+func (f *fakeTB) Failed() bool          { return f.failed }
+func (f *fakeTB) Cleanup(fn func())     { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Log(args ...any)       { f.logs = append(f.logs, fmt.Sprint(args...)) }
+func (f *fakeTB) Errorf(string, ...any) { f.failed = true }
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.failed = true
+	f.fatals = append(f.fatals, fmt.Sprintf(format, args...))
+	runtime.Goexit()
+}
+
+// run calls fn like a test function would be, stopping at Fatalf
+func (f *fakeTB) run(fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+}
+
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestRequireLogged(t *testing.T) {
+	logger := New(t)
+
+	requests := logger.Named("http").With(zap.String("request_id", "r-1"))
+	requests.Info("request served",
+		zap.Int("status", 200),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.String("user.id", "u-7"),
+	)
+	requests.Error("request failed", zap.Error(errors.New("timeout")))
+	logger.Debug("cache miss")
+
+	logger.RequireLogged(t, jsonlog.InfoLevel, "request served")
+	logger.RequireLogged(t, jsonlog.InfoLevel, "request served",
+		zap.String("request_id", "r-1"),
+		zap.Int64("status", 200),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.String("user.id", "u-7"),
+	)
+	logger.RequireLogged(t, jsonlog.ErrorLevel, "request failed", zap.Error(errors.New("timeout")))
+	logger.RequireLogged(t, jsonlog.DebugLevel, "cache miss")
+
+	if entries := logger.Entries(); len(entries) != 3 || entries[0]["logger"] != "http" {
+		t.Errorf("unexpected entries: %v", entries)
+	}
+	if errs := logger.FilterEntries(jsonlog.FilterByMinLevel(jsonlog.WarnLevel)); len(errs) != 1 || errs[0]["error"] != "timeout" {
+		t.Errorf("unexpected filtered entries: %v", errs)
+	}
+
+	logger.Reset()
+	if entries := logger.Entries(); len(entries) != 0 {
+		t.Errorf("Reset kept %d entries", len(entries))
+	}
+}
+
+func TestRequireLoggedFailure(t *testing.T) {
+	fake := &fakeTB{TB: t}
+	logger := New(fake)
+	logger.Warn("disk almost full", zap.Int("free_mb", 12))
+
+	for _, check := range []func(){
+		func() { logger.RequireLogged(fake, jsonlog.InfoLevel, "disk almost full") },
+		func() { logger.RequireLogged(fake, jsonlog.WarnLevel, "disk full") },
+		func() { logger.RequireLogged(fake, jsonlog.WarnLevel, "disk almost full", zap.Int("free_mb", 13)) },
+		func() { logger.RequireLogged(fake, jsonlog.WarnLevel, "disk almost full", zap.String("mount", "/var")) },
+	} {
+		fake.run(check)
+	}
+	if len(fake.fatals) != 4 {
+		t.Fatalf("expected 4 failures, got %d: %q", len(fake.fatals), fake.fatals)
+	}
+	if !strings.Contains(fake.fatals[2], `{"free_mb":13}`) || !strings.Contains(fake.fatals[2], `"free_mb":12`) {
+		t.Errorf("failure should show the expectation and the captured entries: %s", fake.fatals[2])
+	}
+
+	// The captured entries are logged when the test has failed
+	fake.finish()
+	if len(fake.logs) != 1 || !strings.Contains(fake.logs[0], "disk almost full") {
+		t.Errorf("unexpected test output: %q", fake.logs)
+	}
+}
+
+func TestPassingTestStaysQuiet(t *testing.T) {
+	fake := &fakeTB{TB: t}
+	logger := New(fake)
+	logger.Info("started")
+	fake.run(func() { logger.RequireLogged(fake, jsonlog.InfoLevel, "started") })
+	fake.finish()
+
+	if fake.failed || len(fake.logs) != 0 {
+		t.Errorf("a passing test should log nothing: %q", fake.logs)
+	}
+}
+
+func TestNewWithConfig(t *testing.T) {
+	logger := NewWithConfig(t, jsonlog.Config{
+		Level:     jsonlog.InfoLevel,
+		Preset:    jsonlog.OTelPreset,
+		Redaction: &jsonlog.RedactionConfig{Keys: []string{"password"}},
+	})
+
+	logger.Debug("below the minimum")
+	logger.Info("login", zap.String("user", "ana"), zap.String("password", "hunter2"))
+
+	entries := logger.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %v", entries)
+	}
+	attributes, ok := entries[0]["Attributes"].(map[string]interface{})
+	if !ok || entries[0]["SeverityText"] != "INFO" {
+		t.Fatalf("preset layout was not used: %v", entries[0])
+	}
+	if attributes["password"] == "hunter2" {
+		t.Errorf("redaction did not apply: %v", attributes)
+	}
+	logger.RequireLogged(t, jsonlog.InfoLevel, "login", zap.String("user", "ana"))
+}
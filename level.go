@@ -0,0 +1,137 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// toZapLevel converts a LogLevel to its zap equivalent
+func toZapLevel(level LogLevel) (zapcore.Level, error) {
+	switch level {
+	case DebugLevel:
+		return zapcore.DebugLevel, nil
+	case InfoLevel:
+		return zapcore.InfoLevel, nil
+	case WarnLevel:
+		return zapcore.WarnLevel, nil
+	case ErrorLevel:
+		return zapcore.ErrorLevel, nil
+	case FatalLevel:
+		return zapcore.FatalLevel, nil
+	case PanicLevel:
+		return zapcore.PanicLevel, nil
+	default:
+		return zapcore.DebugLevel, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// fromZapLevel converts a zap level to the closest LogLevel
+func fromZapLevel(level zapcore.Level) LogLevel {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return DebugLevel
+	case level == zapcore.InfoLevel:
+		return InfoLevel
+	case level == zapcore.WarnLevel:
+		return WarnLevel
+	case level == zapcore.ErrorLevel:
+		return ErrorLevel
+	case level == zapcore.FatalLevel:
+		return FatalLevel
+	default:
+		return PanicLevel
+	}
+}
+
+// newAtomicLevel builds the shared minimum level (empty = debug)
+func newAtomicLevel(level LogLevel) (zap.AtomicLevel, error) {
+	if level == "" {
+		level = DebugLevel
+	}
+	zapLevel, err := toZapLevel(level)
+	if err != nil {
+		return zap.AtomicLevel{}, err
+	}
+	return zap.NewAtomicLevelAt(zapLevel), nil
+}
+
+// SetLevel changes the minimum level for all outputs. It is safe to call
+// while other goroutines are logging.
+func (l *Logger) SetLevel(level LogLevel) error {
+	zapLevel, err := toZapLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(zapLevel)
+	return nil
+}
+
+// Level returns the current minimum level
+func (l *Logger) Level() LogLevel {
+	return fromZapLevel(l.level.Level())
+}
+
+// LevelHandler returns an HTTP handler that reports the current level on
+// GET and changes it on PUT, using the same JSON body as zap's
+// AtomicLevel handler: {"level":"warn"}. Only the LogLevel names are
+// accepted, so that GET always reports the level that was set.
+func (l *Logger) LevelHandler() http.Handler {
+	return levelHandler{l.level}
+}
+
+// levelHandler serves the shared level like zap.AtomicLevel, restricted to
+// the levels toZapLevel knows
+type levelHandler struct {
+	level zap.AtomicLevel
+}
+
+type levelPayload struct {
+	Level LogLevel `json:"level"`
+}
+
+func (h levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	fail := func(status int, err error) {
+		w.WriteHeader(status)
+		enc.Encode(struct {
+			Error string `json:"error"`
+		}{err.Error()})
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var requested LogLevel
+		if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+			requested = LogLevel(r.FormValue("level"))
+		} else {
+			var payload levelPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				fail(http.StatusBadRequest, fmt.Errorf("malformed request body: %w", err))
+				return
+			}
+			requested = payload.Level
+		}
+		if requested == "" {
+			fail(http.StatusBadRequest, errors.New("must specify logging level"))
+			return
+		}
+		zapLevel, err := toZapLevel(LogLevel(strings.ToLower(string(requested))))
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		h.level.SetLevel(zapLevel)
+	default:
+		fail(http.StatusMethodNotAllowed, errors.New("only GET and PUT are supported"))
+		return
+	}
+	enc.Encode(levelPayload{fromZapLevel(h.level.Level())})
+}
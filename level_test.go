@@ -0,0 +1,168 @@
+package jsonlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConfigLevel(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Level:       WarnLevel,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.Close()
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+
+	if strings.Contains(string(content), "info message") {
+		t.Error("info message should be filtered out")
+	}
+	if !strings.Contains(string(content), "warn message") {
+		t.Error("warn message should be written")
+	}
+}
+
+func TestConfigLevelInvalid(t *testing.T) {
+	if _, err := NewLogger(Config{LogPath: t.TempDir(), Level: "verbose"}); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	if logger.Level() != DebugLevel {
+		t.Errorf("expected default level debug, got %s", logger.Level())
+	}
+
+	if err := logger.SetLevel(ErrorLevel); err != nil {
+		t.Fatalf("failed to set level: %v", err)
+	}
+	if logger.Level() != ErrorLevel {
+		t.Errorf("expected level error, got %s", logger.Level())
+	}
+	if err := logger.SetLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+
+	logger.Warn("dropped warning")
+	logger.Error("kept error")
+	logger.Close()
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if strings.Contains(string(content), "dropped warning") {
+		t.Error("warning should be filtered out")
+	}
+	if !strings.Contains(string(content), "kept error") {
+		t.Error("error should be written")
+	}
+}
+
+func TestSetLevelConcurrent(t *testing.T) {
+	logger, err := NewLogger(Config{LogPath: t.TempDir(), LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Info("concurrent message")
+			}
+		}()
+	}
+
+	levels := []LogLevel{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+	for i := 0; i < 100; i++ {
+		if err := logger.SetLevel(levels[i%len(levels)]); err != nil {
+			t.Errorf("failed to set level: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestLevelHandler(t *testing.T) {
+	logger, err := NewLogger(Config{LogPath: t.TempDir(), LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	handler := logger.LevelHandler()
+
+	req := httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"warn"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if logger.Level() != WarnLevel {
+		t.Errorf("expected level warn, got %s", logger.Level())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/level", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"warn"`) {
+		t.Errorf("expected current level in response, got %s", rec.Body.String())
+	}
+
+	// Form values work as with zap's handler
+	req = httptest.NewRequest(http.MethodPut, "/level", strings.NewReader("level=ERROR"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || logger.Level() != ErrorLevel {
+		t.Errorf("form PUT: status %d, level %s", rec.Code, logger.Level())
+	}
+
+	// zap levels without a LogLevel, such as dpanic, are rejected
+	for _, body := range []string{`{"level":"dpanic"}`, `{"level":"loud"}`, `{}`, `not json`} {
+		req = httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(body))
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"error"`) {
+			t.Errorf("%s: expected status 400 with an error, got %d: %s", body, rec.Code, rec.Body.String())
+		}
+	}
+	if logger.Level() != ErrorLevel {
+		t.Errorf("a rejected PUT changed the level to %s", logger.Level())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/level", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for POST, got %d", rec.Code)
+	}
+}
@@ -14,14 +14,13 @@
 //	if err != nil {
 //		panic(err)
 //	}
-//	defer logger.Close()
 //
 //	// Log messages with structured fields
 //	logger.Info("User login", zap.String("user_id", "user123"))
 //	logger.Error("Database error", zap.String("error", "connection timeout"))
 //
-//	// Compress logs after closing
-//	logger.CompressLogFile()
+//	// Close flushes the file and, with CompressOnClose, writes app.log.gz
+//	logger.Close()
 //
 //	// Read compressed logs with filtering
 //	logs, err := jsonlog.ReadCompressedLogsFiltered(
@@ -31,13 +30,14 @@
 package jsonlog
 
 import (
-	"compress/gzip"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"go.uber.org/zap"
@@ -59,10 +59,19 @@ const (
 
 // Logger is the main logging service
 type Logger struct {
-	zapLogger  *zap.Logger
-	filePath   string
-	fileLogger *lumberjack.Logger
-	mu         sync.Mutex
+	zapLogger       *zap.Logger
+	filePath        string
+	fileLogger      *lumberjack.Logger
+	level           zap.AtomicLevel
+	schema          Schema
+	extractors      []ContextExtractor
+	compressOnClose bool
+	child           bool
+	asyncWriters    []*asyncWriter
+	sinks           []*isolatedSink
+	closeHooks      []func() error
+	root            *Logger // the logger NewLogger returned, which owns the file
+	mu              *sync.Mutex
 }
 
 // Config holds the logger configuration
@@ -73,85 +82,382 @@ type Config struct {
 	// LogFileName is the name of the log file (without extension)
 	LogFileName string
 
+	// Level is the minimum level written (empty = debug). It can be changed
+	// later with Logger.SetLevel
+	Level LogLevel
+
+	// ContextExtractors pull fields such as trace or request IDs out of the
+	// context passed to the *Ctx logging methods
+	ContextExtractors []ContextExtractor
+
+	// Schema sets the record keys and the time and level encodings
+	// (zero = DefaultSchema). Read the files back with the same Schema.
+	Schema Schema
+
+	// Preset writes records in a standard layout (ECSPreset, OTelPreset)
+	// instead of Schema; the two cannot be combined. Read the files back
+	// with Preset.Schema or Logger.Schema.
+	Preset Preset
+
+	// Redaction masks or hashes sensitive fields before they reach any
+	// output (nil = no redaction). See DefaultRedactionConfig.
+	Redaction *RedactionConfig
+
+	// Sampling writes only the first entries per level and message in each
+	// tick, then every Nth (nil = no sampling)
+	Sampling *SamplingConfig
+
+	// RateLimit limits entries per level and message with a token bucket
+	// (nil = no limit)
+	RateLimit *RateLimitConfig
+
+	// DropSummaryInterval is how often the counts of entries dropped by
+	// Sampling and RateLimit are written as a summary record (0 = 1 minute)
+	DropSummaryInterval time.Duration
+
+	// Sinks are extra destinations, each with its own level and encoding.
+	// A failing sink does not affect the file or the other sinks.
+	Sinks []SinkConfig
+
+	// CloseHooks run once when the logger is closed, including by Fatal and
+	// RecoverAndLog before the process exits. They run in order before the
+	// outputs are flushed, so they may still log; they must not call Close.
+	// Their errors are returned by Close.
+	CloseHooks []func() error
+
+	// Async queues encoded entries and writes them from a background
+	// flusher, so logging calls do not wait for I/O (nil = synchronous)
+	Async *AsyncConfig
+
+	// File configures the log file output (default encoding: JSON)
+	File OutputConfig
+
+	// DisableFile turns the log file off, for loggers that only write to
+	// Console or Sinks. LogPath is then optional and the compression
+	// options cannot be used.
+	DisableFile bool
+
+	// Console enables printing to stdout when set (default encoding: console)
+	Console *OutputConfig
+
 	// EnableConsoleOutput determines if logs should also be printed to console
+	//
+	// Deprecated: set Console instead; this is equivalent to &OutputConfig{}
 	EnableConsoleOutput bool
 
 	// CompressOnClose enables gzip compression when closing
 	CompressOnClose bool
 
-	// RotationSize is the max size in bytes before rotation (0 = no rotation)
+	// CompressRotated gzip-compresses rotated files in the background. Close
+	// waits for it and returns any file that could not be compressed.
+	CompressRotated bool
+
+	// RotationSize is the max size in bytes before rotation. It is rounded
+	// down to whole megabytes and must be at least 1 MB (0 = 100 MB default)
 	RotationSize int64
+
+	// MaxBackups is the number of rotated files to keep
+	// (0 = default of 3, -1 = keep all)
+	MaxBackups int
+
+	// MaxAge is the number of days to keep rotated files
+	// (0 = default of 28, -1 = no age limit)
+	MaxAge int
+
+	// LocalTime uses local time instead of UTC in rotated file names
+	LocalTime bool
+}
+
+const (
+	megabyte = 1024 * 1024
+
+	defaultRotationSize = 100 * megabyte
+	defaultMaxBackups   = 3
+	defaultMaxAge       = 28 // days
+)
+
+// newFileLogger validates the rotation settings and builds the lumberjack
+// writer for the given file path
+func newFileLogger(config Config, filePath string) (*lumberjack.Logger, error) {
+	rotationSize := config.RotationSize
+	switch {
+	case rotationSize == 0:
+		rotationSize = defaultRotationSize
+	case rotationSize < megabyte:
+		return nil, fmt.Errorf("RotationSize must be at least %d bytes, got %d", megabyte, rotationSize)
+	}
+
+	maxBackups := config.MaxBackups
+	switch {
+	case maxBackups == 0:
+		maxBackups = defaultMaxBackups
+	case maxBackups == -1:
+		maxBackups = 0 // lumberjack keeps all backups when zero
+	case maxBackups < 0:
+		return nil, fmt.Errorf("MaxBackups must be -1 or greater, got %d", maxBackups)
+	}
+
+	maxAge := config.MaxAge
+	switch {
+	case maxAge == 0:
+		maxAge = defaultMaxAge
+	case maxAge == -1:
+		maxAge = 0 // lumberjack does not remove files by age when zero
+	case maxAge < 0:
+		return nil, fmt.Errorf("MaxAge must be -1 or greater, got %d", maxAge)
+	}
+
+	return &lumberjack.Logger{
+		Filename:   filePath,
+		MaxSize:    int(rotationSize / megabyte),
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+		LocalTime:  config.LocalTime,
+	}, nil
 }
 
 // NewLogger creates a new logger instance
 func NewLogger(config Config) (*Logger, error) {
 	// Validate config
-	if config.LogPath == "" {
+	if config.LogPath == "" && !config.DisableFile {
 		return nil, fmt.Errorf("LogPath is required")
 	}
+	if config.DisableFile && (config.CompressOnClose || config.CompressRotated) {
+		return nil, fmt.Errorf("CompressOnClose and CompressRotated require the log file, but DisableFile is set")
+	}
 
 	if config.LogFileName == "" {
 		config.LogFileName = "app"
 	}
 
+	// Build file path
+	var logFilePath string
+	var fileLogger *lumberjack.Logger
+	if !config.DisableFile {
+		logFilePath = filepath.Join(config.LogPath, config.LogFileName+".log")
+
+		// Validate rotation settings before touching the filesystem
+		var err error
+		if fileLogger, err = newFileLogger(config, logFilePath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate minimum level
+	level, err := newAtomicLevel(config.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Level: %w", err)
+	}
+
+	// Validate record schema
+	schema := config.Schema.withDefaults()
+	if err := schema.validate(); err != nil {
+		return nil, fmt.Errorf("invalid Schema: %w", err)
+	}
+	if config.Preset != "" {
+		if config.Schema != (Schema{}) {
+			return nil, fmt.Errorf("Preset and Schema cannot both be set")
+		}
+		if schema, err = config.Preset.Schema(); err != nil {
+			return nil, fmt.Errorf("invalid Preset: %w", err)
+		}
+	}
+
 	// Create log directory if it doesn't exist
-	if err := os.MkdirAll(config.LogPath, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	if config.LogPath != "" {
+		if err := os.MkdirAll(config.LogPath, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
 	}
 
-	// Build file path
-	logFilePath := filepath.Join(config.LogPath, config.LogFileName+".log")
-
-	// Create Zap logger configuration
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        "timestamp",
-		LevelKey:       "level",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		FunctionKey:    zapcore.OmitKey,
-		MessageKey:     "message",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeTime:     zapcore.ISO8601TimeEncoder,
-		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
+	// Async outputs queue their writes; the flushers start once the logger
+	// is built
+	var asyncWriters []*asyncWriter
+	writer := func(out zapcore.WriteSyncer) zapcore.WriteSyncer { return out }
+	if config.Async != nil {
+		async, err := validateAsync(*config.Async)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Async: %w", err)
+		}
+		writer = func(out zapcore.WriteSyncer) zapcore.WriteSyncer {
+			w := newAsyncWriter(out, async)
+			asyncWriters = append(asyncWriters, w)
+			return w
+		}
 	}
 
 	var cores []zapcore.Core
 
-	// File output (always JSON) - using lumberjack for proper file handle management
-	fileEncoder := zapcore.NewJSONEncoder(encoderConfig)
-	fileLogger := &lumberjack.Logger{
-		Filename:   logFilePath,
-		MaxSize:    100, // megabytes
-		MaxBackups: 3,
-		MaxAge:     28, // days
+	// File output - using lumberjack for proper file handle management
+	var compressor *rotatedCompressor
+	if fileLogger != nil {
+		var fileWriter io.Writer = fileLogger
+		if config.CompressRotated {
+			compressor = newRotatedCompressor(fileLogger, logFilePath, int64(fileLogger.MaxSize)*megabyte)
+			fileWriter = compressor
+		}
+		fileCore, err := newOutputCore(config.File, JSONEncoding, schema, writer(zapcore.AddSync(fileWriter)), level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid File output: %w", err)
+		}
+		cores = append(cores, fileCore)
 	}
-	fileSync := zapcore.AddSync(fileLogger)
-	fileCore := zapcore.NewCore(fileEncoder, fileSync, zapcore.DebugLevel)
-	cores = append(cores, fileCore)
 
 	// Console output (if enabled)
-	if config.EnableConsoleOutput {
-		consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-		consoleCore := zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), zapcore.DebugLevel)
+	console := config.Console
+	if console == nil && config.EnableConsoleOutput {
+		console = &OutputConfig{}
+	}
+	if console != nil {
+		consoleCore, err := newOutputCore(*console, ConsoleEncoding, schema, writer(zapcore.AddSync(os.Stdout)), level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Console output: %w", err)
+		}
 		cores = append(cores, consoleCore)
 	}
 
-	// Create combined logger
-	combinedCore := zapcore.NewTee(cores...)
-	zapLogger := zap.New(combinedCore, zap.AddCaller())
+	// Extra sinks. Network and HTTP sinks start working when opened, so
+	// they are closed again if a later setting is invalid.
+	var sinks []*isolatedSink
+	built := false
+	defer func() {
+		if !built {
+			for _, sink := range sinks {
+				sink.close()
+			}
+		}
+	}()
+	for i, sinkConfig := range config.Sinks {
+		sink, err := openSink(sinkConfig, config.LogPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Sinks[%d]: %w", i, err)
+		}
+		sinks = append(sinks, sink)
+		sinkCore, err := newSinkCore(sink, sinkConfig.OutputConfig, schema, level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Sinks[%d]: %w", i, err)
+		}
+		cores = append(cores, sinkCore)
+	}
+
+	// Create combined logger, redacting before any output sees the entry
+	var combinedCore zapcore.Core = outputTee(cores)
+	if config.Redaction != nil {
+		redactor, err := NewRedactor(*config.Redaction)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redaction: %w", err)
+		}
+		combinedCore = redactor.Wrap(combinedCore)
+	}
+
+	// Configured hooks run first, while every output is still open
+	var closeHooks []func() error
+	if len(config.CloseHooks) > 0 {
+		closeHooks = append(closeHooks, runOnce(config.CloseHooks))
+	}
+
+	// Sample and rate limit in front of the outputs, so that teed outputs
+	// keep or drop the same entries
+	if config.Sampling != nil || config.RateLimit != nil {
+		interval := config.DropSummaryInterval
+		if interval < 0 {
+			return nil, fmt.Errorf("DropSummaryInterval must not be negative, got %s", interval)
+		}
+		if interval == 0 {
+			interval = defaultDropSummaryInterval
+		}
+
+		stats := newDropStats(combinedCore)
+		if config.Sampling != nil {
+			if combinedCore, err = newSamplingCore(combinedCore, *config.Sampling, stats); err != nil {
+				return nil, fmt.Errorf("invalid Sampling: %w", err)
+			}
+		}
+		if config.RateLimit != nil {
+			if stats.limiter, err = newRateLimiter(*config.RateLimit); err != nil {
+				return nil, fmt.Errorf("invalid RateLimit: %w", err)
+			}
+			combinedCore = &rateLimitCore{Core: combinedCore, limiter: stats.limiter, stats: stats}
+		}
+
+		stats.start(interval)
+		closeHooks = append(closeHooks, stats.close)
+	}
+
+	// Drain the queues after the last drop summary
+	for _, w := range asyncWriters {
+		w.start()
+		closeHooks = append(closeHooks, w.close)
+	}
+	for _, sink := range sinks {
+		closeHooks = append(closeHooks, sink.close)
+	}
+
+	// Compress the last rotated files once nothing else is written
+	if compressor != nil {
+		compressor.start()
+		closeHooks = append(closeHooks, compressor.close)
+	}
 
 	logger := &Logger{
-		zapLogger:  zapLogger,
-		filePath:   logFilePath,
-		fileLogger: fileLogger,
+		filePath:        logFilePath,
+		fileLogger:      fileLogger,
+		level:           level,
+		schema:          schema,
+		extractors:      config.ContextExtractors,
+		compressOnClose: config.CompressOnClose,
+		asyncWriters:    asyncWriters,
+		sinks:           sinks,
+		closeHooks:      closeHooks,
+		mu:              &sync.Mutex{},
 	}
+	logger.root = logger
+	logger.zapLogger = zap.New(combinedCore, zap.AddCaller(), zap.AddCallerSkip(1), zap.WithFatalHook(fatalHook{logger}))
 
+	built = true
 	return logger, nil
 }
 
+// Schema returns the record schema the logger writes, for building
+// matching filters and readers
+func (l *Logger) Schema() Schema {
+	return l.schema
+}
+
+// AsyncStats returns the counters of the async outputs, summed. It is zero
+// when Config.Async is not set.
+func (l *Logger) AsyncStats() AsyncStats {
+	var stats AsyncStats
+	for _, w := range l.asyncWriters {
+		s := w.stats()
+		stats.Queued += s.Queued
+		stats.Written += s.Written
+		stats.Dropped += s.Dropped
+		stats.Failed += s.Failed
+	}
+	return stats
+}
+
+// With returns a child logger that adds the given fields to every entry.
+// The child shares the parent's outputs; closing it only flushes buffers.
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	return l.derive(l.zapLogger.With(fields...))
+}
+
+// Named returns a child logger with name appended to the logger name,
+// written under the "logger" key. Segments are joined with periods.
+func (l *Logger) Named(name string) *Logger {
+	return l.derive(l.zapLogger.Named(name))
+}
+
+// derive copies l around a new zap logger and marks the copy as a child
+func (l *Logger) derive(zapLogger *zap.Logger) *Logger {
+	child := *l
+	child.zapLogger = zapLogger
+	child.child = true
+	return &child
+}
+
 // Debug logs a debug message
 func (l *Logger) Debug(message string, fields ...zap.Field) {
 	l.zapLogger.Debug(message, fields...)
@@ -172,179 +478,145 @@ func (l *Logger) Error(message string, fields ...zap.Field) {
 	l.zapLogger.Error(message, fields...)
 }
 
-// Fatal logs a fatal message and exits
+// Fatal logs a fatal message, closes the logger (running close hooks and
+// CompressOnClose) and exits
 func (l *Logger) Fatal(message string, fields ...zap.Field) {
 	l.zapLogger.Fatal(message, fields...)
 }
 
-// Panic logs a panic message
+// Panic logs a panic message, flushes every output and sink, and panics.
+// The logger stays open, since the panic may be recovered.
 func (l *Logger) Panic(message string, fields ...zap.Field) {
+	defer l.zapLogger.Sync()
 	l.zapLogger.Panic(message, fields...)
 }
 
-// LogWithLevel logs a message with specified level
+// LogWithLevel logs a message with specified level (info when unknown)
 func (l *Logger) LogWithLevel(level LogLevel, message string, fields ...zap.Field) {
-	switch level {
-	case DebugLevel:
-		l.Debug(message, fields...)
-	case InfoLevel:
-		l.Info(message, fields...)
-	case WarnLevel:
-		l.Warn(message, fields...)
-	case ErrorLevel:
-		l.Error(message, fields...)
-	case FatalLevel:
-		l.Fatal(message, fields...)
-	case PanicLevel:
-		l.Panic(message, fields...)
-	default:
-		l.Info(message, fields...)
+	zapLevel := levelOrInfo(level)
+	if zapLevel == zapcore.PanicLevel {
+		defer l.zapLogger.Sync()
 	}
-}
-
-// Close closes the logger and flushes buffers
-func (l *Logger) Close() error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// Sync zap logger
-	if err := l.zapLogger.Sync(); err != nil && err.Error() != "sync /dev/stdout: The handle is invalid" {
-		return fmt.Errorf("failed to sync logger: %w", err)
+	// Check here rather than going through Info and the like, so that the
+	// caller is the one of LogWithLevel
+	if checked := l.zapLogger.Check(zapLevel, message); checked != nil {
+		checked.Write(fields...)
 	}
+}
 
-	// Close lumberjack logger to release file handle
-	if l.fileLogger != nil {
-		if err := l.fileLogger.Close(); err != nil {
-			return fmt.Errorf("failed to close file logger: %w", err)
-		}
+// levelOrInfo converts level for LogWithLevel, falling back to info
+func levelOrInfo(level LogLevel) zapcore.Level {
+	zapLevel, err := toZapLevel(level)
+	if err != nil {
+		return zapcore.InfoLevel
 	}
-
-	return nil
+	return zapLevel
 }
 
-// CompressLogFile compresses the log file with gzip
-func (l *Logger) CompressLogFile() error {
+// Close closes the logger and flushes buffers. On a child logger created
+// with With or Named it only flushes; the parent owns the file. A failed
+// step does not skip the later ones: the file is still closed and
+// compressed, and all errors are returned together.
+func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if _, err := os.Stat(l.filePath); err != nil {
-		return fmt.Errorf("log file not found: %w", err)
-	}
-
-	// Create compressed file path
-	compressedPath := l.filePath + ".gz"
-
-	// Open source file
-	source, err := os.Open(l.filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+	// Stop background writers so their last records are synced below, and
+	// close the sinks. Their errors are returned once the file is closed.
+	var hookErrs []error
+	if !l.child {
+		for _, hook := range l.closeHooks {
+			hookErrs = append(hookErrs, hook())
+		}
 	}
-	defer source.Close()
 
-	// Create destination file
-	destination, err := os.Create(compressedPath)
-	if err != nil {
-		return fmt.Errorf("failed to create compressed file: %w", err)
+	// Sync zap logger. A failed sync does not stop the file from being
+	// closed and compressed; its error is returned with the others.
+	var errs []error
+	if err := syncError(l.zapLogger.Sync()); err != nil {
+		errs = append(errs, fmt.Errorf("failed to sync logger: %w", err))
 	}
-	defer destination.Close()
 
-	// Create gzip writer
-	gzipWriter := gzip.NewWriter(destination)
-	defer gzipWriter.Close()
-
-	// Copy content
-	if _, err := io.Copy(gzipWriter, source); err != nil {
-		return fmt.Errorf("failed to compress: %w", err)
+	if l.child {
+		return errors.Join(errs...)
 	}
 
-	// Flush gzip writer
-	if err := gzipWriter.Flush(); err != nil {
-		return fmt.Errorf("failed to flush gzip writer: %w", err)
+	// Close lumberjack logger to release file handle
+	fileClosed := true
+	if l.fileLogger != nil {
+		if err := l.fileLogger.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close file logger: %w", err))
+			fileClosed = false
+		}
 	}
 
-	return nil
-}
-
-// ReadCompressedLogs reads and decompresses logs from a gzip file
-func ReadCompressedLogs(filePath string) ([]map[string]interface{}, error) {
-	// Open compressed file
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open compressed file: %w", err)
+	// Compress the closed file if requested
+	if l.compressOnClose && fileClosed {
+		if err := l.compressLogFile(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to compress on close: %w", err))
+		}
 	}
-	defer file.Close()
 
-	// Create gzip reader
-	gzipReader, err := gzip.NewReader(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	if err := errors.Join(hookErrs...); err != nil {
+		errs = append(errs, fmt.Errorf("failed to run close hook: %w", err))
 	}
-	defer gzipReader.Close()
-
-	// Decode JSON lines directly from gzip stream
-	var logs []map[string]interface{}
-	decoder := json.NewDecoder(gzipReader)
+	return errors.Join(errs...)
+}
 
-	for decoder.More() {
-		var logEntry map[string]interface{}
-		if err := decoder.Decode(&logEntry); err != nil {
-			continue // Skip malformed lines
-		}
-		logs = append(logs, logEntry)
+// runOnce returns a close hook that runs hooks in order the first time it
+// is called and joins their errors
+func runOnce(hooks []func() error) func() error {
+	hooks = append([]func() error(nil), hooks...)
+	var once sync.Once
+	return func() error {
+		var errs []error
+		once.Do(func() {
+			for _, hook := range hooks {
+				errs = append(errs, hook())
+			}
+		})
+		return errors.Join(errs...)
 	}
-
-	return logs, nil
 }
 
-// ReadCompressedLogsFiltered reads and filters logs from a gzip file
-func ReadCompressedLogsFiltered(filePath string, filter FilterFunc) ([]map[string]interface{}, error) {
-	logs, err := ReadCompressedLogs(filePath)
-	if err != nil {
-		return nil, err
+// syncError drops the errors of syncing a terminal or pipe such as stdout,
+// which is not a file: EINVAL or ENOTSUP on Linux and macOS, "The handle is
+// invalid" on Windows
+func syncError(err error) error {
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = multi.Unwrap()
 	}
 
-	var filtered []map[string]interface{}
-	for _, log := range logs {
-		if filter(log) {
-			filtered = append(filtered, log)
+	var kept []error
+	for _, err := range errs {
+		if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) || strings.HasSuffix(err.Error(), "The handle is invalid") {
+			continue
 		}
+		kept = append(kept, err)
 	}
-
-	return filtered, nil
+	return errors.Join(kept...)
 }
 
-// FilterFunc is a function type for filtering logs
-type FilterFunc func(log map[string]interface{}) bool
+// CompressLogFile compresses the log file with gzip
+func (l *Logger) CompressLogFile() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-// FilterByLevel creates a filter for a specific log level
-func FilterByLevel(level string) FilterFunc {
-	return func(log map[string]interface{}) bool {
-		if l, ok := log["level"]; ok {
-			return l == level
-		}
-		return false
-	}
+	return l.compressLogFile()
 }
 
-// FilterByTimeRange creates a filter for logs within a time range
-func FilterByTimeRange(start, end time.Time) FilterFunc {
-	return func(log map[string]interface{}) bool {
-		if ts, ok := log["timestamp"].(string); ok {
-			// Try RFC3339Nano first (with colon in timezone)
-			t, err := time.Parse(time.RFC3339Nano, ts)
-			if err != nil {
-				// Try the format without colon in timezone: 2025-12-02T15:59:57.317+0800
-				t, err = time.Parse("2006-01-02T15:04:05.000-0700", ts)
-				if err != nil {
-					// Try with 3-digit milliseconds
-					t, err = time.Parse("2006-01-02T15:04:05.000Z0700", ts)
-					if err != nil {
-						return false
-					}
-				}
-			}
-			return t.After(start) && t.Before(end)
-		}
-		return false
+// compressLogFile writes filePath.gz; the caller must hold l.mu
+func (l *Logger) compressLogFile() error {
+	if l.fileLogger == nil {
+		return errors.New("the log file is disabled")
 	}
+	if _, err := os.Stat(l.filePath); err != nil {
+		return fmt.Errorf("log file not found: %w", err)
+	}
+
+	return gzipFile(l.filePath, l.filePath+".gz")
 }
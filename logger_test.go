@@ -1,9 +1,14 @@
 package jsonlog
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -31,6 +36,37 @@ func TestNewLogger(t *testing.T) {
 	}
 }
 
+func TestDisableFile(t *testing.T) {
+	sink := &syncBuffer{}
+	logger, err := NewLogger(Config{
+		DisableFile: true,
+		Sinks:       []SinkConfig{{Writer: sink}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("no file")
+	if err := logger.CompressLogFile(); err == nil {
+		t.Error("CompressLogFile should fail without a log file")
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("failed to close logger: %v", err)
+	}
+	if !strings.Contains(sink.String(), "no file") {
+		t.Errorf("sink did not receive the entry: %q", sink.String())
+	}
+
+	for _, config := range []Config{
+		{DisableFile: true, CompressOnClose: true},
+		{DisableFile: true, CompressRotated: true},
+	} {
+		if _, err := NewLogger(config); err == nil {
+			t.Errorf("expected validation error for %+v", config)
+		}
+	}
+}
+
 func TestLogLevels(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -97,6 +133,43 @@ func TestLogWithLevel(t *testing.T) {
 	}
 }
 
+func TestCaller(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	ctx := context.Background()
+	logger.Info("direct")
+	logger.InfoCtx(ctx, "with context")
+	logger.LogWithLevel(WarnLevel, "with level")
+	logger.LogWithLevelCtx(ctx, WarnLevel, "with level and context")
+	logger.With(zap.String("k", "v")).Named("child").LogWithLevel("unknown", "child")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("LogWithLevel(PanicLevel) should panic")
+			}
+		}()
+		logger.LogWithLevel(PanicLevel, "panicking")
+	}()
+	logger.Close()
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 6 {
+		t.Fatalf("expected 6 records, got %d", len(records))
+	}
+	for _, record := range records {
+		if caller, _ := record["caller"].(string); !strings.Contains(caller, "logger_test.go:") {
+			t.Errorf("%s: caller should be the test, got %q", record["message"], caller)
+		}
+	}
+	if records[4]["level"] != "info" {
+		t.Errorf("an unknown level should log at info, got %v", records[4]["level"])
+	}
+}
+
 func TestCompressLogFile(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -322,3 +395,332 @@ func TestJSONFormat(t *testing.T) {
 		t.Error("count field is missing or incorrect")
 	}
 }
+
+func TestRotationConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := Config{
+		LogPath:      tmpDir,
+		LogFileName:  "test",
+		RotationSize: 5 * 1024 * 1024,
+		MaxBackups:   10,
+		MaxAge:       -1,
+		LocalTime:    true,
+	}
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	if logger.fileLogger.MaxSize != 5 {
+		t.Errorf("expected MaxSize 5, got %d", logger.fileLogger.MaxSize)
+	}
+	if logger.fileLogger.MaxBackups != 10 {
+		t.Errorf("expected MaxBackups 10, got %d", logger.fileLogger.MaxBackups)
+	}
+	if logger.fileLogger.MaxAge != 0 {
+		t.Errorf("expected MaxAge 0 (no limit), got %d", logger.fileLogger.MaxAge)
+	}
+	if !logger.fileLogger.LocalTime {
+		t.Error("expected LocalTime to be set")
+	}
+}
+
+func TestRotationConfigDefaults(t *testing.T) {
+	logger, err := NewLogger(Config{LogPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	if logger.fileLogger.MaxSize != 100 || logger.fileLogger.MaxBackups != 3 || logger.fileLogger.MaxAge != 28 {
+		t.Errorf("unexpected defaults: size=%d backups=%d age=%d",
+			logger.fileLogger.MaxSize, logger.fileLogger.MaxBackups, logger.fileLogger.MaxAge)
+	}
+}
+
+func TestRotationConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"rotation size too small", Config{RotationSize: 1024}},
+		{"negative rotation size", Config{RotationSize: -1}},
+		{"invalid max backups", Config{MaxBackups: -2}},
+		{"invalid max age", Config{MaxAge: -5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LogPath = t.TempDir()
+			if _, err := NewLogger(tt.config); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:      tmpDir,
+		LogFileName:  "test",
+		RotationSize: 1024 * 1024,
+		MaxBackups:   -1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	payload := string(make([]byte, 1024))
+	for i := 0; i < 2048; i++ {
+		logger.Info("filler", zap.String("payload", payload))
+	}
+	logger.Close()
+
+	matches, err := filepath.Glob(filepath.Join(tmpDir, "test-*.log"))
+	if err != nil {
+		t.Fatalf("failed to glob backups: %v", err)
+	}
+	if len(matches) == 0 {
+		t.Error("expected at least one rotated backup")
+	}
+}
+
+func TestCompressOnClose(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		CompressOnClose: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("test message 1")
+	logger.Info("test message 2")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	logs, err := ReadCompressedLogs(filepath.Join(tmpDir, "test.log.gz"))
+	if err != nil {
+		t.Fatalf("failed to read compressed logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("expected 2 logs, got %d", len(logs))
+	}
+}
+
+// pipeStdout points os.Stdout at a pipe, which cannot be synced, for
+// loggers created until the test ends
+func pipeStdout(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(io.Discard, reader)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		writer.Close()
+		<-done
+		reader.Close()
+	})
+}
+
+func TestCompressOnCloseWithPipedConsole(t *testing.T) {
+	pipeStdout(t)
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		Console:         &OutputConfig{},
+		Sinks:           []SinkConfig{{URL: "stdout"}},
+		CompressOnClose: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("to a pipe")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("syncing a pipe should not fail Close: %v", err)
+	}
+	logs, err := ReadCompressedLogs(filepath.Join(tmpDir, "test.log.gz"))
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("archive not written: %v, %v", logs, err)
+	}
+}
+
+func TestSyncError(t *testing.T) {
+	real := errors.New("disk failure")
+	err := syncError(errors.Join(
+		&os.PathError{Op: "sync", Path: "/dev/stdout", Err: syscall.EINVAL},
+		&os.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.ENOTSUP},
+		real,
+	))
+	if !errors.Is(err, real) || errors.Is(err, syscall.EINVAL) {
+		t.Errorf("only the real error should be kept, got %v", err)
+	}
+	if err := syncError(&os.PathError{Op: "sync", Path: "/dev/stdout", Err: syscall.EINVAL}); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestCloseHooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	hookErr := errors.New("flush failed")
+	calls := 0
+
+	var logger *Logger
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		CompressOnClose: true,
+		CloseHooks: []func() error{
+			func() error {
+				calls++
+				logger.Info("final stats", zap.Int("requests", 42))
+				return nil
+			},
+			func() error { return hookErr },
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	if err := logger.Named("child").Close(); err != nil || calls != 0 {
+		t.Fatalf("a child Close should not run the hooks: %v, %d calls", err, calls)
+	}
+	if err := logger.Close(); !errors.Is(err, hookErr) {
+		t.Errorf("Close should return the hook error, got %v", err)
+	}
+	if err := logger.Close(); err != nil || calls != 1 {
+		t.Errorf("hooks should run once: %v, %d calls", err, calls)
+	}
+
+	// The hook ran before the file was closed and compressed
+	logs, err := ReadCompressedLogs(filepath.Join(tmpDir, "test.log.gz"))
+	if err != nil || len(logs) != 1 || logs[0]["message"] != "final stats" {
+		t.Errorf("entry logged by the hook missing: %v, %v", logs, err)
+	}
+}
+
+func TestCompressOnCloseError(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		CompressOnClose: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("test message")
+
+	// A directory in place of the archive makes compression fail
+	if err := os.Mkdir(filepath.Join(tmpDir, "test.log.gz"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	if err := logger.Close(); err == nil {
+		t.Error("expected compression error from Close")
+	}
+}
+
+func TestCompressRotated(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		RotationSize:    1024 * 1024,
+		CompressRotated: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	payload := string(make([]byte, 1024))
+	for i := 0; i < 2048; i++ {
+		logger.Info("filler", zap.String("payload", payload))
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	// Close waits for the rotated backups to be compressed
+	compressed, _ := filepath.Glob(filepath.Join(tmpDir, "test-*.log.gz"))
+	plain, _ := filepath.Glob(filepath.Join(tmpDir, "test-*.log"))
+	if len(compressed) == 0 || len(plain) != 0 {
+		t.Fatalf("expected only compressed backups, got %v and %v", compressed, plain)
+	}
+	logs, err := ReadCompressedLogs(compressed[0])
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	if len(logs) == 0 || logs[0]["message"] != "filler" {
+		t.Errorf("unexpected backup content: %d entries", len(logs))
+	}
+}
+
+func TestWithAndNamed(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "test",
+		CompressOnClose: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	child := logger.With(zap.String("request_id", "req-1")).Named("http").Named("handler")
+	child.Info("child message", zap.String("tenant", "acme"))
+
+	// Closing the child flushes but leaves the parent's file alone
+	if err := child.Close(); err != nil {
+		t.Fatalf("failed to close child: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "test.log.gz")); !os.IsNotExist(err) {
+		t.Error("closing a child should not compress the parent's file")
+	}
+
+	logger.Info("parent message")
+	logger.Close()
+
+	logs, err := ReadCompressedLogs(filepath.Join(tmpDir, "test.log.gz"))
+	if err != nil {
+		t.Fatalf("failed to read compressed logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(logs))
+	}
+
+	if logs[0]["request_id"] != "req-1" || logs[0]["tenant"] != "acme" {
+		t.Errorf("child entry missing bound fields: %v", logs[0])
+	}
+	if logs[0]["logger"] != "http.handler" {
+		t.Errorf("expected logger name 'http.handler', got '%v'", logs[0]["logger"])
+	}
+	if _, ok := logs[1]["request_id"]; ok {
+		t.Error("parent entry should not carry child fields")
+	}
+}
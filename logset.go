@@ -0,0 +1,151 @@
+package jsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the timestamp lumberjack puts in rotated file names,
+// e.g. app-2025-12-02T15-59-57.317.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// gzipMagic is the header every gzip stream starts with
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// FindLogSet lists the files that make up the log baseName in dir, oldest
+// first: rotated backups (plain or gzip) ordered by their rotation time,
+// then the active baseName.log. The baseName.log.gz archive written by
+// CompressOnClose is a copy of the active file, so it is only included when
+// baseName.log no longer exists. Likewise a backup found both plain and
+// gzipped, as while CompressRotated replaces it, is listed once, gzipped.
+func FindLogSet(dir, baseName string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	type backup struct {
+		path    string
+		rotated time.Time
+	}
+
+	var backups []backup
+	byStamp := make(map[string]int) // index in backups
+	var active, archive string
+	prefix := baseName + "-"
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+
+		switch name {
+		case baseName + ".log":
+			active = path
+			continue
+		case baseName + ".log.gz":
+			archive = path
+			continue
+		}
+
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], ".gz"), ".log")
+		rotated, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue // Not a lumberjack backup of this log
+		}
+		if i, ok := byStamp[stamp]; ok {
+			// The .gz is complete once it has its name; the plain file is
+			// about to be removed
+			if strings.HasSuffix(name, ".gz") {
+				backups[i].path = path
+			}
+			continue
+		}
+		byStamp[stamp] = len(backups)
+		backups = append(backups, backup{path: path, rotated: rotated})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotated.Before(backups[j].rotated)
+	})
+
+	files := make([]string, 0, len(backups)+1)
+	for _, b := range backups {
+		files = append(files, b.path)
+	}
+	switch {
+	case active != "":
+		files = append(files, active)
+	case archive != "":
+		files = append(files, archive)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no log files named %q found in %s", baseName, dir)
+	}
+	return files, nil
+}
+
+// OpenLogSet streams every file of the log baseName in dir (see FindLogSet)
+// in chronological order. Compression is detected per file. The caller must
+// Close the reader.
+//
+//	reader, err := jsonlog.OpenLogSet("./logs", "app",
+//		jsonlog.FilterByTimeRange(time.Now().Add(-6*time.Hour), time.Now()))
+func OpenLogSet(dir, baseName string, filter FilterFunc) (*Reader, error) {
+	files, err := FindLogSet(dir, baseName)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &Reader{filter: filter, pending: files}
+	if err := reader.openNext(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// OpenLogFile opens a plain or gzip log file for streaming, detecting
+// compression from the file content. The caller must Close the reader.
+func OpenLogFile(filePath string, filter FilterFunc) (*Reader, error) {
+	reader := &Reader{filter: filter, pending: []string{filePath}}
+	if err := reader.openNext(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// openLogSource opens a log file and decompresses it if it starts with the
+// gzip magic bytes
+func openLogSource(filePath string) (*bufio.Reader, []io.Closer, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(len(gzipMagic))
+	if !bytes.Equal(magic, gzipMagic) {
+		return buffered, []io.Closer{file}, nil
+	}
+
+	gzipReader, err := gzip.NewReader(buffered)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to create gzip reader for %s: %w", filePath, err)
+	}
+	return bufio.NewReader(gzipReader), []io.Closer{gzipReader, file}, nil
+}
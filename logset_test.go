@@ -0,0 +1,189 @@
+package jsonlog
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func writeFile(t *testing.T, path, content string, compress bool) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+
+	if !compress {
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		return
+	}
+
+	gzipWriter := gzip.NewWriter(file)
+	if _, err := gzipWriter.Write([]byte(content)); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+}
+
+func TestFindLogSet(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeFile(t, filepath.Join(tmpDir, "app-2025-12-02T10-00-00.000.log.gz"), `{"message":"b"}`+"\n", true)
+	writeFile(t, filepath.Join(tmpDir, "app-2025-12-01T10-00-00.000.log"), `{"message":"a"}`+"\n", false)
+	writeFile(t, filepath.Join(tmpDir, "app.log"), `{"message":"c"}`+"\n", false)
+	writeFile(t, filepath.Join(tmpDir, "app.log.gz"), `{"message":"c"}`+"\n", true)
+	writeFile(t, filepath.Join(tmpDir, "other-2025-12-01T10-00-00.000.log"), `{"message":"x"}`+"\n", false)
+	writeFile(t, filepath.Join(tmpDir, "app-notes.log"), `{"message":"x"}`+"\n", false)
+
+	files, err := FindLogSet(tmpDir, "app")
+	if err != nil {
+		t.Fatalf("failed to find log set: %v", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	want := "app-2025-12-01T10-00-00.000.log,app-2025-12-02T10-00-00.000.log.gz,app.log"
+	if strings.Join(names, ",") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(names, ","))
+	}
+
+	reader, err := OpenLogSet(tmpDir, "app", nil)
+	if err != nil {
+		t.Fatalf("failed to open log set: %v", err)
+	}
+	defer reader.Close()
+
+	var messages []string
+	for reader.Next() {
+		messages = append(messages, reader.Entry()["message"].(string))
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("unexpected reader error: %v", err)
+	}
+	if strings.Join(messages, ",") != "a,b,c" {
+		t.Errorf("expected entries in chronological order, got %v", messages)
+	}
+}
+
+func TestFindLogSetArchiveOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile(t, filepath.Join(tmpDir, "app.log.gz"), `{"message":"a"}`+"\n", true)
+
+	files, err := FindLogSet(tmpDir, "app")
+	if err != nil {
+		t.Fatalf("failed to find log set: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "app.log.gz" {
+		t.Errorf("expected the archive when the active file is gone, got %v", files)
+	}
+
+	if _, err := FindLogSet(tmpDir, "missing"); err == nil {
+		t.Error("expected error for an unknown log name")
+	}
+}
+
+func TestFindLogSetBackupBeingCompressed(t *testing.T) {
+	tmpDir := t.TempDir()
+	// The state between the rename and the remove in compressBackup
+	writeFile(t, filepath.Join(tmpDir, "app-2025-12-01T10-00-00.000.log"), `{"message":"a"}`+"\n", false)
+	writeFile(t, filepath.Join(tmpDir, "app-2025-12-01T10-00-00.000.log.gz"), `{"message":"a"}`+"\n", true)
+	writeFile(t, filepath.Join(tmpDir, "app.log"), `{"message":"b"}`+"\n", false)
+
+	files, err := FindLogSet(tmpDir, "app")
+	if err != nil {
+		t.Fatalf("failed to find log set: %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	want := "app-2025-12-01T10-00-00.000.log.gz,app.log"
+	if strings.Join(names, ",") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(names, ","))
+	}
+
+	reader, err := OpenLogSet(tmpDir, "app", nil)
+	if err != nil {
+		t.Fatalf("failed to open log set: %v", err)
+	}
+	defer reader.Close()
+
+	var messages []string
+	for reader.Next() {
+		messages = append(messages, reader.Entry()["message"].(string))
+	}
+	if strings.Join(messages, ",") != "a,b" {
+		t.Errorf("expected each entry once, got %v", messages)
+	}
+}
+
+func TestOpenLogSetRotated(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:      tmpDir,
+		LogFileName:  "test",
+		RotationSize: 1024 * 1024,
+		MaxBackups:   -1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	payload := strings.Repeat("x", 1024)
+	for i := 0; i < 2048; i++ {
+		logger.Info("filler", zap.Int("index", i), zap.String("payload", payload))
+	}
+	logger.Close()
+
+	reader, err := OpenLogSet(tmpDir, "test", nil)
+	if err != nil {
+		t.Fatalf("failed to open log set: %v", err)
+	}
+	defer reader.Close()
+
+	var count int
+	for reader.Next() {
+		if reader.Entry()["index"] != float64(count) {
+			t.Fatalf("expected index %d, got %v", count, reader.Entry()["index"])
+		}
+		count++
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("unexpected reader error: %v", err)
+	}
+	if count != 2048 {
+		t.Errorf("expected 2048 entries, got %d", count)
+	}
+}
+
+func TestOpenLogFileDetectsCompression(t *testing.T) {
+	tmpDir := t.TempDir()
+	plain := filepath.Join(tmpDir, "plain.log")
+	compressed := filepath.Join(tmpDir, "compressed.dat")
+	writeFile(t, plain, `{"message":"plain"}`+"\n", false)
+	writeFile(t, compressed, `{"message":"compressed"}`+"\n", true)
+
+	for path, want := range map[string]string{plain: "plain", compressed: "compressed"} {
+		reader, err := OpenLogFile(path, nil)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", path, err)
+		}
+		if !reader.Next() || reader.Entry()["message"] != want {
+			t.Errorf("expected %q from %s, got %v", want, path, reader.Entry())
+		}
+		reader.Close()
+	}
+}
@@ -0,0 +1,146 @@
+package jsonlog
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Encoding selects how an output formats entries
+type Encoding string
+
+const (
+	JSONEncoding    Encoding = "json"
+	ConsoleEncoding Encoding = "console"
+)
+
+// OutputConfig holds the settings of a single output
+type OutputConfig struct {
+	// Level is the minimum level for this output (empty = Config.Level).
+	// Entries must pass both this and the logger's level.
+	Level LogLevel
+
+	// Encoding is the output format (empty = the output's default)
+	Encoding Encoding
+
+	// Color colorizes level names; only valid with console encoding
+	Color bool
+}
+
+// outputLevel enables entries that pass both the shared runtime level and
+// the output's own minimum level
+type outputLevel struct {
+	global zap.AtomicLevel
+	min    zapcore.Level
+}
+
+func (o outputLevel) Enabled(level zapcore.Level) bool {
+	return level >= o.min && o.global.Enabled(level)
+}
+
+// newOutputCore builds the zap core for one output
+func newOutputCore(
+	output OutputConfig,
+	defaultEncoding Encoding,
+	schema Schema,
+	writer zapcore.WriteSyncer,
+	level zap.AtomicLevel,
+) (zapcore.Core, error) {
+	encoder, enabler, err := newOutputEncoder(output, defaultEncoding, schema, level)
+	if err != nil {
+		return nil, err
+	}
+
+	core := zapcore.NewCore(encoder, writer, enabler)
+	return schema.wrapCore(core), nil
+}
+
+// newOutputEncoder builds the encoder and level of one output
+func newOutputEncoder(
+	output OutputConfig,
+	defaultEncoding Encoding,
+	schema Schema,
+	level zap.AtomicLevel,
+) (zapcore.Encoder, zapcore.LevelEnabler, error) {
+	minLevel := zapcore.DebugLevel
+	if output.Level != "" {
+		var err error
+		if minLevel, err = toZapLevel(output.Level); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	encoding := output.Encoding
+	if encoding == "" {
+		encoding = defaultEncoding
+	}
+
+	encoderConfig := schema.encoderConfig()
+	if output.Color {
+		if encoding != ConsoleEncoding {
+			return nil, nil, fmt.Errorf("color requires %q encoding, got %q", ConsoleEncoding, encoding)
+		}
+		encoderConfig.EncodeLevel = schema.colorLevelEncoder()
+	}
+
+	var encoder zapcore.Encoder
+	switch encoding {
+	case JSONEncoding:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case ConsoleEncoding:
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+
+	return encoder, outputLevel{global: level, min: minLevel}, nil
+}
+
+// outputTee fans entries out to the outputs. Unlike zapcore.NewTee it checks
+// each output's level on Write, so a wrapper around the whole tee (such as
+// redaction) can add it to a CheckedEntry as a single core.
+type outputTee []zapcore.Core
+
+func (t outputTee) Enabled(level zapcore.Level) bool {
+	for _, core := range t {
+		if core.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t outputTee) With(fields []zapcore.Field) zapcore.Core {
+	cores := make(outputTee, len(t))
+	for i, core := range t {
+		cores[i] = core.With(fields)
+	}
+	return cores
+}
+
+func (t outputTee) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if t.Enabled(ent.Level) {
+		return ce.AddCore(ent, t)
+	}
+	return ce
+}
+
+func (t outputTee) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var errs []error
+	for _, core := range t {
+		if core.Enabled(ent.Level) {
+			errs = append(errs, core.Write(ent, fields))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t outputTee) Sync() error {
+	var errs []error
+	for _, core := range t {
+		errs = append(errs, core.Sync())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,111 @@
+package jsonlog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileOutputConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		File:        OutputConfig{Level: InfoLevel, Encoding: ConsoleEncoding},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Close()
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+
+	if strings.Contains(string(content), "debug message") {
+		t.Error("debug message should be filtered out")
+	}
+	if !strings.Contains(string(content), "info message") {
+		t.Error("info message should be written")
+	}
+	if strings.HasPrefix(string(content), "{") {
+		t.Error("expected console encoding, got JSON")
+	}
+}
+
+func TestConsoleOutputConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Capture stdout, which the console output binds to at construction
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Console:     &OutputConfig{Level: WarnLevel, Color: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.Close()
+	w.Close()
+
+	console, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	if strings.Contains(string(console), "info message") {
+		t.Error("console should not show info messages")
+	}
+	if !strings.Contains(string(console), "warn message") {
+		t.Error("console should show warn messages")
+	}
+	if !strings.Contains(string(console), "\x1b[") {
+		t.Error("console output should be colored")
+	}
+
+	// The file keeps debug-level JSON
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(content), `"message":"info message"`) {
+		t.Error("file should contain the info message as JSON")
+	}
+}
+
+func TestOutputConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"unknown encoding", Config{File: OutputConfig{Encoding: "xml"}}},
+		{"unknown level", Config{File: OutputConfig{Level: "verbose"}}},
+		{"color with json", Config{Console: &OutputConfig{Encoding: JSONEncoding, Color: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LogPath = t.TempDir()
+			if _, err := NewLogger(tt.config); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
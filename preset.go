@@ -0,0 +1,240 @@
+package jsonlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Preset names a standard record layout
+type Preset string
+
+const (
+	// ECSPreset writes Elastic Common Schema records: @timestamp,
+	// log.level, message, log.logger, log.origin.file.name/line and
+	// error.stack_trace, plus ecs.version
+	ECSPreset Preset = "ecs"
+
+	// OTelPreset writes the OpenTelemetry logs data model: Timestamp (epoch
+	// nanos), SeverityText, SeverityNumber, Body, InstrumentationScope,
+	// TraceId and SpanId, with every other field under Attributes
+	OTelPreset Preset = "otel"
+)
+
+// ecsVersion is the ECS version the ECS preset follows
+const ecsVersion = "8.11.0"
+
+// Schema returns the record schema of the preset. Use it to build filters
+// and readers for files written with the preset; its NewLogEntry maps the
+// records back into LogEntry.
+func (p Preset) Schema() (Schema, error) {
+	switch p {
+	case ECSPreset:
+		return Schema{
+			TimeKey:       "@timestamp",
+			LevelKey:      "log.level",
+			MessageKey:    "message",
+			CallerKey:     "log.origin.file.name",
+			StacktraceKey: "error.stack_trace",
+			NameKey:       "log.logger",
+			TimeEncoding:  RFC3339NanoTimeEncoding,
+			LevelEncoding: LowercaseLevelEncoding,
+			preset:        p,
+		}, nil
+	case OTelPreset:
+		return Schema{
+			TimeKey:       "Timestamp",
+			LevelKey:      "SeverityText",
+			MessageKey:    "Body",
+			CallerKey:     "code.filepath",
+			StacktraceKey: "exception.stacktrace",
+			NameKey:       "InstrumentationScope",
+			TimeEncoding:  EpochNanosTimeEncoding,
+			LevelEncoding: UppercaseLevelEncoding,
+			preset:        p,
+		}, nil
+	default:
+		return Schema{}, fmt.Errorf("unknown preset %q", p)
+	}
+}
+
+// otelSeverityNumber maps zap levels to OpenTelemetry severity numbers
+func otelSeverityNumber(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	case zapcore.DPanicLevel:
+		return 18
+	default: // panic, fatal
+		return 21
+	}
+}
+
+// otelTopLevelKeys are fields written next to Attributes instead of inside,
+// keyed by the names accepted from callers
+var otelTopLevelKeys = map[string]string{
+	"TraceId":    "TraceId",
+	"trace_id":   "TraceId",
+	"SpanId":     "SpanId",
+	"span_id":    "SpanId",
+	"TraceFlags": "TraceFlags",
+}
+
+// presetCore rewrites entries into a preset's layout before passing them to
+// the output core. Context fields are kept unencoded so that every field can
+// be placed, e.g. under the OTel Attributes object.
+type presetCore struct {
+	zapcore.Core
+	preset  Preset
+	context []zapcore.Field
+}
+
+// wrapCore applies the schema's preset, if any, to an output core
+func (s Schema) wrapCore(core zapcore.Core) zapcore.Core {
+	if s.preset == "" {
+		return core
+	}
+	return &presetCore{Core: core, preset: s.preset}
+}
+
+func (c *presetCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(context, c.context...)
+	context = append(context, fields...)
+	return &presetCore{Core: c.Core, preset: c.preset, context: context}
+}
+
+func (c *presetCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *presetCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.context)+len(fields)+6)
+	all = append(all, c.context...)
+	all = append(all, fields...)
+
+	switch c.preset {
+	case ECSPreset:
+		ent, all = ecsFields(ent, all)
+	case OTelPreset:
+		ent, all = otelFields(ent, all)
+	}
+
+	return c.Core.Write(ent, all)
+}
+
+// ecsFields splits the caller into log.origin fields and adds ecs.version
+func ecsFields(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+	fields = append(fields, zap.String("ecs.version", ecsVersion))
+
+	if ent.Caller.Defined {
+		fields = append(fields,
+			zap.String("log.origin.file.name", callerPath(ent.Caller)),
+			zap.Int("log.origin.file.line", ent.Caller.Line),
+		)
+		if ent.Caller.Function != "" {
+			fields = append(fields, zap.String("log.origin.function", ent.Caller.Function))
+		}
+		ent.Caller = zapcore.EntryCaller{}
+	}
+
+	return ent, fields
+}
+
+// otelFields adds SeverityNumber, keeps trace context at the top level and
+// moves every other field, the caller and the stack trace under Attributes
+func otelFields(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+	top := []zapcore.Field{zap.Int("SeverityNumber", otelSeverityNumber(ent.Level))}
+	attributes := make([]zapcore.Field, 0, len(fields)+3)
+
+	for _, field := range fields {
+		if key, ok := otelTopLevelKeys[field.Key]; ok {
+			field.Key = key
+			top = append(top, field)
+			continue
+		}
+		attributes = append(attributes, field)
+	}
+
+	if ent.Caller.Defined {
+		attributes = append(attributes,
+			zap.String("code.filepath", callerPath(ent.Caller)),
+			zap.Int("code.lineno", ent.Caller.Line),
+		)
+		if ent.Caller.Function != "" {
+			attributes = append(attributes, zap.String("code.function", ent.Caller.Function))
+		}
+		ent.Caller = zapcore.EntryCaller{}
+	}
+	if ent.Stack != "" {
+		attributes = append(attributes, zap.String("exception.stacktrace", ent.Stack))
+		ent.Stack = ""
+	}
+
+	top = append(top, zap.Namespace("Attributes"))
+	return ent, append(top, attributes...)
+}
+
+// callerPath returns the package/file.go form written by ShortCallerEncoder
+func callerPath(caller zapcore.EntryCaller) string {
+	path := caller.TrimmedPath()
+	if i := strings.LastIndexByte(path, ':'); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// normalizePreset maps a preset record onto the flat layout NewLogEntry
+// expects: OTel Attributes are lifted to the top level, and the split
+// caller fields are joined back into "file:line" under CallerKey
+func (s Schema) normalizePreset(log map[string]interface{}) map[string]interface{} {
+	var lineKey string
+	flat := make(map[string]interface{}, len(log))
+
+	switch s.preset {
+	case ECSPreset:
+		lineKey = "log.origin.file.line"
+		for key, value := range log {
+			if key != "ecs.version" {
+				flat[key] = value
+			}
+		}
+	case OTelPreset:
+		lineKey = "code.lineno"
+		for key, value := range log {
+			if key != "SeverityNumber" && key != "Attributes" {
+				flat[key] = value
+			}
+		}
+		if attributes, ok := log["Attributes"].(map[string]interface{}); ok {
+			for key, value := range attributes {
+				if _, exists := flat[key]; !exists {
+					flat[key] = value
+				}
+			}
+		}
+	default:
+		return log
+	}
+
+	file, fileOK := flat[s.CallerKey].(string)
+	line, lineOK := toFloat(flat[lineKey])
+	if fileOK && lineOK {
+		flat[s.CallerKey] = file + ":" + strconv.Itoa(int(line))
+		delete(flat, lineKey)
+	}
+
+	return flat
+}
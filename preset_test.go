@@ -0,0 +1,194 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// readRecords decodes every line of a log file
+func readRecords(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestECSPreset(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test", Preset: ECSPreset})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Named("api").With(zap.String("user_id", "u1")).Warn("slow request", zap.Int("duration_ms", 750))
+	logger.Close()
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	record := records[0]
+	for key, want := range map[string]interface{}{
+		"log.level":   "warn",
+		"message":     "slow request",
+		"log.logger":  "api",
+		"ecs.version": ecsVersion,
+		"user_id":     "u1",
+		"duration_ms": float64(750),
+	} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %v", key, record[key], want)
+		}
+	}
+	if _, ok := record["@timestamp"].(string); !ok {
+		t.Errorf("missing @timestamp: %v", record)
+	}
+	if file, _ := record["log.origin.file.name"].(string); !strings.HasSuffix(file, "preset_test.go") {
+		t.Errorf("unexpected log.origin.file.name: %v", record["log.origin.file.name"])
+	}
+	if _, ok := record["log.origin.file.line"].(float64); !ok {
+		t.Errorf("missing log.origin.file.line: %v", record)
+	}
+	if _, ok := record["caller"]; ok {
+		t.Error("default caller key should not be written")
+	}
+
+	schema := logger.Schema()
+	entries, err := ReadLogEntries(filepath.Join(tmpDir, "test.log"), schema.FilterByMinLevel(WarnLevel))
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	entry := schema.NewLogEntry(records[0])
+	if entry.Level != WarnLevel || entry.Message != "slow request" || entry.Logger != "api" || entry.Time.IsZero() {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if !strings.Contains(entry.Caller, ".go:") {
+		t.Errorf("caller not rebuilt: %q", entry.Caller)
+	}
+	if _, ok := entry.Fields["ecs.version"]; ok {
+		t.Error("ecs.version should not be left in Fields")
+	}
+	if got, _ := entry.String("user_id"); got != "u1" {
+		t.Errorf("user_id = %q", got)
+	}
+}
+
+func TestOTelPreset(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test", Preset: OTelPreset})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	child := logger.With(zap.String("trace_id", "0af7651916cd43dd8448eb211c80319c"))
+	child.Error("payment failed", zap.String("SpanId", "b7ad6b7169203331"), zap.Int("amount", 42))
+	logger.Debug("debug message")
+	logger.Close()
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	record := records[0]
+	for key, want := range map[string]interface{}{
+		"SeverityText":   "ERROR",
+		"SeverityNumber": float64(17),
+		"Body":           "payment failed",
+		"TraceId":        "0af7651916cd43dd8448eb211c80319c",
+		"SpanId":         "b7ad6b7169203331",
+	} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %v", key, record[key], want)
+		}
+	}
+	if _, ok := record["Timestamp"].(float64); !ok {
+		t.Errorf("Timestamp should be epoch nanos: %v", record["Timestamp"])
+	}
+
+	attributes, ok := record["Attributes"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing Attributes: %v", record)
+	}
+	if attributes["amount"] != float64(42) {
+		t.Errorf("amount not in Attributes: %v", attributes)
+	}
+	if _, ok := attributes["trace_id"]; ok {
+		t.Error("trace_id should be hoisted out of Attributes")
+	}
+	if file, _ := attributes["code.filepath"].(string); !strings.HasSuffix(file, ".go") {
+		t.Errorf("unexpected code.filepath: %v", attributes["code.filepath"])
+	}
+	if records[1]["SeverityNumber"] != float64(5) {
+		t.Errorf("debug SeverityNumber = %v", records[1]["SeverityNumber"])
+	}
+
+	schema := logger.Schema()
+	filter, err := schema.ParseQuery("SeverityText>=error AND Attributes.amount>40")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	reader, err := OpenLogFile(filepath.Join(tmpDir, "test.log"), filter)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer reader.Close()
+	reader.Schema = schema
+
+	if !reader.Next() {
+		t.Fatalf("expected the error entry, err: %v", reader.Err())
+	}
+	entry := reader.LogEntry()
+	if entry.Level != ErrorLevel || entry.Message != "payment failed" || entry.Time.IsZero() {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if !strings.Contains(entry.Caller, ".go:") {
+		t.Errorf("caller not rebuilt: %q", entry.Caller)
+	}
+	if n, _ := entry.Int64("amount"); n != 42 {
+		t.Errorf("amount = %d", n)
+	}
+	if _, ok := entry.Fields["SeverityNumber"]; ok {
+		t.Error("SeverityNumber should not be left in Fields")
+	}
+	if reader.Next() {
+		t.Errorf("unexpected entry: %v", reader.Entry())
+	}
+}
+
+func TestPresetValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"unknown preset", Config{Preset: "gelf"}},
+		{"preset with schema", Config{Preset: ECSPreset, Schema: Schema{MessageKey: "msg"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LogPath = t.TempDir()
+			if _, err := NewLogger(tt.config); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
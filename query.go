@@ -0,0 +1,443 @@
+package jsonlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// QueryError reports a problem in a query string
+type QueryError struct {
+	Pos int // byte offset in the query
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query error at position %d: %s", e.Pos, e.Msg)
+}
+
+// ParseQuery compiles a text query into a FilterFunc, e.g.
+//
+//	level>=warn AND user_id="u1" AND duration_ms>500 AND timestamp>"2025-12-01"
+//
+// A query is made of comparisons joined with AND, OR and NOT (case
+// insensitive, NOT binds tightest, then AND) and grouped with parentheses.
+// A comparison is a field, an operator and a value:
+//
+//	=  !=  <  <=  >  >=   compare values
+//	~                     match a regular expression
+//	CONTAINS              match a substring
+//	EXISTS                test for the field (takes no value)
+//
+// Values are numbers, double-quoted strings or bare words. Field names may
+// use dots to reach into nested objects. The level field compares by
+// severity, so level>=warn keeps warn, error, fatal and panic entries. The
+// timestamp field compares as a time; values may be RFC 3339 timestamps or
+// dates such as "2025-12-01".
+func ParseQuery(query string) (FilterFunc, error) {
+	return DefaultSchema().ParseQuery(query)
+}
+
+// ParseQuery compiles a text query for records written with this schema.
+// The schema's LevelKey and TimeKey take the place of level and timestamp.
+func (s Schema) ParseQuery(query string) (FilterFunc, error) {
+	p := &queryParser{lexer: queryLexer{input: query}, schema: s.withDefaults()}
+	p.advance()
+
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.token)
+	}
+	return filter, nil
+}
+
+// MustParseQuery is like ParseQuery but panics if the query is invalid
+func MustParseQuery(query string) FilterFunc {
+	filter, err := ParseQuery(query)
+	if err != nil {
+		panic(err)
+	}
+	return filter
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenInvalid
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string // unquoted text for strings, a description for invalid tokens
+	pos  int
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return strconv.Quote(t.text)
+	case tokenInvalid:
+		return t.text
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// keyword reports whether the token is the given case-insensitive keyword
+func (t queryToken) keyword(name string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, name)
+}
+
+type queryLexer struct {
+	input string
+	pos   int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-@:+", r)
+}
+
+func (l *queryLexer) next() queryToken {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if start >= len(l.input) {
+		return queryToken{kind: tokenEOF, pos: start}
+	}
+
+	switch c := l.input[start]; {
+	case c == '(':
+		l.pos++
+		return queryToken{kind: tokenLParen, text: "(", pos: start}
+	case c == ')':
+		l.pos++
+		return queryToken{kind: tokenRParen, text: ")", pos: start}
+	case c == '"':
+		return l.quoted()
+	case strings.IndexByte("=!<>~", c) >= 0:
+		l.pos++
+		if l.pos < len(l.input) && l.input[l.pos] == '=' && c != '=' && c != '~' {
+			l.pos++
+		}
+		op := l.input[start:l.pos]
+		if op == "!" {
+			return queryToken{kind: tokenInvalid, text: `invalid operator "!"`, pos: start}
+		}
+		return queryToken{kind: tokenOperator, text: op, pos: start}
+	}
+
+	for l.pos < len(l.input) {
+		r := rune(l.input[l.pos])
+		if r >= 0x80 || isWordRune(r) {
+			l.pos++
+			continue
+		}
+		break
+	}
+	if l.pos == start {
+		l.pos++
+		return queryToken{kind: tokenInvalid, text: fmt.Sprintf("invalid character %q", l.input[start:l.pos]), pos: start}
+	}
+
+	text := l.input[start:l.pos]
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return queryToken{kind: tokenNumber, text: text, pos: start}
+	}
+	return queryToken{kind: tokenWord, text: text, pos: start}
+}
+
+// quoted reads a double-quoted string with Go escape sequences
+func (l *queryLexer) quoted() queryToken {
+	start := l.pos
+	for i := start + 1; i < len(l.input); i++ {
+		switch l.input[i] {
+		case '\\':
+			i++
+		case '"':
+			l.pos = i + 1
+			text, err := strconv.Unquote(l.input[start:l.pos])
+			if err != nil {
+				return queryToken{kind: tokenInvalid, text: "invalid string " + l.input[start:l.pos], pos: start}
+			}
+			return queryToken{kind: tokenString, text: text, pos: start}
+		}
+	}
+	l.pos = len(l.input)
+	return queryToken{kind: tokenInvalid, text: "unterminated string", pos: start}
+}
+
+type queryParser struct {
+	lexer  queryLexer
+	token  queryToken
+	schema Schema
+}
+
+func (p *queryParser) advance() {
+	p.token = p.lexer.next()
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return &QueryError{Pos: p.token.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseOr handles: and ("OR" and)*
+func (p *queryParser) parseOr() (FilterFunc, error) {
+	filters, err := p.parseList("OR", p.parseAnd)
+	if err != nil || len(filters) == 1 {
+		return firstFilter(filters), err
+	}
+	return Or(filters...), nil
+}
+
+// parseAnd handles: unary ("AND" unary)*
+func (p *queryParser) parseAnd() (FilterFunc, error) {
+	filters, err := p.parseList("AND", p.parseUnary)
+	if err != nil || len(filters) == 1 {
+		return firstFilter(filters), err
+	}
+	return And(filters...), nil
+}
+
+func (p *queryParser) parseList(keyword string, parse func() (FilterFunc, error)) ([]FilterFunc, error) {
+	var filters []FilterFunc
+	for {
+		filter, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+		if !p.token.keyword(keyword) {
+			return filters, nil
+		}
+		p.advance()
+	}
+}
+
+func firstFilter(filters []FilterFunc) FilterFunc {
+	if len(filters) == 0 {
+		return nil
+	}
+	return filters[0]
+}
+
+// parseUnary handles: "NOT" unary | "(" or ")" | comparison
+func (p *queryParser) parseUnary() (FilterFunc, error) {
+	switch {
+	case p.token.keyword("NOT"):
+		p.advance()
+		filter, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(filter), nil
+
+	case p.token.kind == tokenLParen:
+		p.advance()
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.token.kind != tokenRParen {
+			return nil, p.errorf("expected \")\", got %s", p.token)
+		}
+		p.advance()
+		return filter, nil
+	}
+
+	return p.parseComparison()
+}
+
+// parseComparison handles: field operator value | field "EXISTS"
+func (p *queryParser) parseComparison() (FilterFunc, error) {
+	if p.token.kind != tokenWord || isQueryKeyword(p.token) {
+		return nil, p.errorf("expected field name, got %s", p.token)
+	}
+	field := p.token.text
+	p.advance()
+
+	var op string
+	switch {
+	case p.token.kind == tokenOperator:
+		op = p.token.text
+	case p.token.keyword("CONTAINS"):
+		op = "contains"
+	case p.token.keyword("EXISTS"):
+		p.advance()
+		return FieldExists(field), nil
+	default:
+		return nil, p.errorf("expected operator after %q, got %s", field, p.token)
+	}
+	p.advance()
+
+	value := p.token
+	switch value.kind {
+	case tokenString, tokenNumber:
+	case tokenWord:
+		if isQueryKeyword(value) {
+			return nil, p.errorf("expected value, got %s", value)
+		}
+	default:
+		return nil, p.errorf("expected value, got %s", value)
+	}
+
+	filter, err := p.schema.compileComparison(field, op, value)
+	if err != nil {
+		return nil, &QueryError{Pos: value.pos, Msg: err.Error()}
+	}
+	p.advance()
+	return filter, nil
+}
+
+func isQueryKeyword(t queryToken) bool {
+	for _, keyword := range []string{"AND", "OR", "NOT", "CONTAINS", "EXISTS"} {
+		if t.keyword(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileComparison builds the filter for one comparison
+func (s Schema) compileComparison(field, op string, value queryToken) (FilterFunc, error) {
+	switch op {
+	case "~":
+		pattern, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+		return FieldMatches(field, pattern), nil
+	case "contains":
+		return FieldContains(field, value.text), nil
+	}
+
+	switch field {
+	case s.LevelKey:
+		return s.compileLevelComparison(op, value.text)
+	case s.TimeKey:
+		return s.compileTimeComparison(op, value.text)
+	}
+
+	if value.kind == tokenNumber {
+		n, _ := strconv.ParseFloat(value.text, 64)
+		return compileNumberComparison(field, op, n), nil
+	}
+	return compileStringComparison(field, op, value.text), nil
+}
+
+// compareOrdered evaluates op for the result of a three-way comparison
+func compareOrdered(op string, cmp int) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+func (s Schema) compileLevelComparison(op, value string) (FilterFunc, error) {
+	want, err := toZapLevel(LogLevel(strings.ToLower(value)))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(log map[string]interface{}) bool {
+		got, ok := s.entryLevel(log)
+		if !ok {
+			return op == "!="
+		}
+		return compareOrdered(op, int(got)-int(want))
+	}, nil
+}
+
+// queryTimeLayouts are accepted for timestamp values in queries, in
+// addition to the layouts the encoder writes
+var queryTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func (s Schema) compileTimeComparison(op, value string) (FilterFunc, error) {
+	want, err := parseTimestamp(value)
+	if err != nil {
+		for _, layout := range queryTimeLayouts {
+			if want, err = time.ParseInLocation(layout, value, time.Local); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", value)
+	}
+
+	return func(log map[string]interface{}) bool {
+		got, ok := s.entryTime(log)
+		if !ok {
+			return op == "!="
+		}
+		return compareOrdered(op, got.Compare(want))
+	}, nil
+}
+
+func compileNumberComparison(field, op string, want float64) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		value, ok := lookupField(log, field)
+		if !ok {
+			return op == "!="
+		}
+		got, ok := toFloat(value)
+		if !ok {
+			return op == "!="
+		}
+		switch {
+		case got < want:
+			return compareOrdered(op, -1)
+		case got > want:
+			return compareOrdered(op, 1)
+		default:
+			return compareOrdered(op, 0)
+		}
+	}
+}
+
+func compileStringComparison(field, op, want string) FilterFunc {
+	return func(log map[string]interface{}) bool {
+		value, ok := lookupField(log, field)
+		if !ok {
+			return op == "!="
+		}
+		// Bare words such as true or null compare against the JSON text
+		got, ok := value.(string)
+		if !ok {
+			got = fmt.Sprint(value)
+			if value == nil {
+				got = "null"
+			}
+		}
+		return compareOrdered(op, strings.Compare(got, want))
+	}
+}
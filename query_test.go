@@ -0,0 +1,92 @@
+package jsonlog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	log := map[string]interface{}{
+		"timestamp":   "2025-12-02T15:59:57.317+0800",
+		"level":       "error",
+		"message":     "payment timeout",
+		"user_id":     "u1",
+		"duration_ms": float64(750),
+		"retry":       true,
+		"http": map[string]interface{}{
+			"status": float64(504),
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`level>=warn AND user_id="u1" AND duration_ms>500 AND timestamp>"2025-12-01"`, true},
+		{`level>=warn`, true},
+		{`level<warn`, false},
+		{`level=ERROR`, true},
+		{`level!=error`, false},
+		{`user_id=u1`, true},
+		{`user_id!="u2"`, true},
+		{`duration_ms<=750`, true},
+		{`duration_ms>750`, false},
+		{`http.status>=500`, true},
+		{`timestamp<2025-12-02`, false},
+		{`timestamp>="2025-12-02T07:59:57Z"`, true},
+		{`message CONTAINS "timeout"`, true},
+		{`message ~ "^pay.*out$"`, true},
+		{`retry=true`, true},
+		{`trace_id EXISTS`, false},
+		{`NOT trace_id EXISTS`, true},
+		{`level=info OR duration_ms>500`, true},
+		{`level=info OR user_id=u2 AND duration_ms>500`, false},
+		{`(level=info OR user_id=u1) and duration_ms>500`, true},
+		{`missing=1`, false},
+		{`missing!=1`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filter, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+			if got := filter(log); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{``, 0},
+		{`level>=`, 7},
+		{`level>=verbose`, 7},
+		{`user_id="u1" AND`, 16},
+		{`user_id "u1"`, 8},
+		{`(level=info`, 11},
+		{`message ~ "(["`, 10},
+		{`timestamp>"yesterday"`, 10},
+		{`user_id="u1`, 8},
+		{`level ! info`, 6},
+		{`level=info)`, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("expected QueryError, got %v", err)
+			}
+			if queryErr.Pos != tt.pos {
+				t.Errorf("expected position %d, got %d (%v)", tt.pos, queryErr.Pos, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package jsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrStopScan can be returned from a ScanCompressedLogs callback to stop
+// reading early without reporting an error
+var ErrStopScan = errors.New("jsonlog: stop scan")
+
+// MalformedLineError describes a line that is not a JSON object
+type MalformedLineError struct {
+	File string // source file, empty for readers created with NewReader
+	Line int    // 1-based line number within File
+	Raw  []byte // line content without the line ending
+	Err  error  // decoding error
+}
+
+func (e *MalformedLineError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("malformed log entry at %s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("malformed log entry at line %d: %v", e.Line, e.Err)
+}
+
+func (e *MalformedLineError) Unwrap() error {
+	return e.Err
+}
+
+// Reader streams log entries one at a time, so archives of any size are
+// read in constant memory. Input is read line by line; a malformed line is
+// skipped and reading resumes at the next line unless Strict is set.
+//
+//	reader, err := jsonlog.OpenCompressedLogs("./logs/app.log.gz", nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer reader.Close()
+//
+//	for reader.Next() {
+//		entry := reader.Entry()
+//		// ...
+//	}
+//	return reader.Err()
+type Reader struct {
+	// Strict makes Next stop at the first malformed line; Err returns it
+	// as a *MalformedLineError
+	Strict bool
+
+	// OnMalformed is called for each malformed line that is skipped
+	OnMalformed func(err *MalformedLineError)
+
+	// Schema describes the records for LogEntry (zero = DefaultSchema)
+	Schema Schema
+
+	source  *bufio.Reader
+	filter  FilterFunc
+	file    string
+	pending []string // files still to read, see OpenLogSet
+	line    int
+	entry   map[string]interface{}
+	err     error
+	closers []io.Closer
+	parse   func(line []byte) (map[string]interface{}, error) // nil = JSON
+}
+
+// NewReader creates a reader over an uncompressed stream of JSON lines.
+// Only entries accepted by filter are returned (nil = all entries).
+func NewReader(r io.Reader, filter FilterFunc) *Reader {
+	return &Reader{
+		source: bufio.NewReader(r),
+		filter: filter,
+	}
+}
+
+// OpenCompressedLogs opens a gzip log file for streaming. The caller must
+// Close the reader.
+func OpenCompressedLogs(filePath string, filter FilterFunc) (*Reader, error) {
+	// Open compressed file
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open compressed file: %w", err)
+	}
+
+	// Create gzip reader
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+
+	reader := NewReader(gzipReader, filter)
+	reader.file = filePath
+	reader.closers = []io.Closer{gzipReader, file}
+	return reader, nil
+}
+
+// Next advances to the next matching entry. It returns false at the end of
+// the stream or on error; check Err afterwards.
+func (r *Reader) Next() bool {
+	r.entry = nil
+	if r.err != nil {
+		return false
+	}
+
+	for {
+		line, readErr := r.source.ReadBytes('\n')
+		if len(line) > 0 {
+			r.line++
+			if logEntry, ok := r.decodeLine(line); ok {
+				if r.filter == nil || r.filter(logEntry) {
+					r.entry = logEntry
+					return true
+				}
+			} else if r.err != nil {
+				return false
+			}
+		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				r.err = fmt.Errorf("failed to read log stream: %w", readErr)
+				return false
+			}
+			if len(r.pending) == 0 {
+				return false
+			}
+			if err := r.openNext(); err != nil {
+				r.err = err
+				return false
+			}
+		}
+	}
+}
+
+// openNext closes the current file and continues with the next pending one
+func (r *Reader) openNext() error {
+	if err := r.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", r.file, err)
+	}
+
+	filePath := r.pending[0]
+	r.pending = r.pending[1:]
+
+	source, closers, err := openLogSource(filePath)
+	if err != nil {
+		return err
+	}
+
+	r.source = source
+	r.closers = closers
+	r.file = filePath
+	r.line = 0
+	return nil
+}
+
+// decodeLine parses one line. Blank lines are ignored; malformed lines are
+// reported to OnMalformed, or stored in r.err in strict mode.
+func (r *Reader) decodeLine(line []byte) (map[string]interface{}, bool) {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil, false
+	}
+
+	var logEntry map[string]interface{}
+	var err error
+	if r.parse != nil {
+		logEntry, err = r.parse(line)
+	} else {
+		logEntry, err = r.decodeJSON(line)
+		if err == nil && logEntry == nil {
+			err = errors.New("entry is not a JSON object")
+		}
+	}
+	if err == nil {
+		return logEntry, true
+	}
+
+	malformed := &MalformedLineError{File: r.file, Line: r.line, Raw: line, Err: err}
+	if r.Strict {
+		r.err = malformed
+	} else if r.OnMalformed != nil {
+		r.OnMalformed(malformed)
+	}
+	return nil, false
+}
+
+// decodeJSON decodes a JSON record. Numbers are float64 as with
+// json.Unmarshal, except an epoch nanos timestamp, which float64 cannot hold
+// exactly: it is kept as a json.Number for Schema.parseTime.
+func (r *Reader) decodeJSON(line []byte) (map[string]interface{}, error) {
+	var logEntry map[string]interface{}
+	schema := r.Schema.withDefaults()
+	if schema.TimeEncoding != EpochNanosTimeEncoding {
+		err := json.Unmarshal(line, &logEntry)
+		return logEntry, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&logEntry); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after the JSON value")
+	}
+	for key, value := range logEntry {
+		if key != schema.TimeKey {
+			logEntry[key] = numbersToFloat(value)
+		}
+	}
+	return logEntry, nil
+}
+
+// numbersToFloat replaces the json.Numbers in a decoded value by float64
+func numbersToFloat(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = numbersToFloat(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbersToFloat(item)
+		}
+	}
+	return value
+}
+
+// Entry returns the entry read by the last successful call to Next
+func (r *Reader) Entry() map[string]interface{} {
+	return r.entry
+}
+
+// Err returns the first error encountered while reading
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Close releases the underlying file, if the reader opened one
+func (r *Reader) Close() error {
+	var firstErr error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	r.closers = nil
+	return firstErr
+}
+
+// ScanCompressedLogs streams the entries of a gzip log file that match
+// filter (nil = all) to fn. Returning ErrStopScan from fn stops reading
+// without error; any other error stops reading and is returned.
+func ScanCompressedLogs(filePath string, filter FilterFunc, fn func(entry map[string]interface{}) error) error {
+	reader, err := OpenCompressedLogs(filePath, filter)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for reader.Next() {
+		if err := fn(reader.Entry()); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return reader.Err()
+}
+
+// ReadCompressedLogs reads and decompresses logs from a gzip file
+func ReadCompressedLogs(filePath string) ([]map[string]interface{}, error) {
+	return ReadCompressedLogsFiltered(filePath, nil)
+}
+
+// ReadCompressedLogsFiltered reads and filters logs from a gzip file
+func ReadCompressedLogsFiltered(filePath string, filter FilterFunc) ([]map[string]interface{}, error) {
+	var logs []map[string]interface{}
+	err := ScanCompressedLogs(filePath, filter, func(entry map[string]interface{}) error {
+		logs = append(logs, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,226 @@
+package jsonlog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// writeCompressedLogs writes count info entries and returns the .gz path
+func writeCompressedLogs(t *testing.T, count int) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test", CompressOnClose: true})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		logger.Info(fmt.Sprintf("message %d", i), zap.Int("index", i))
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	return filepath.Join(tmpDir, "test.log.gz")
+}
+
+func TestScanCompressedLogs(t *testing.T) {
+	path := writeCompressedLogs(t, 10)
+
+	var count int
+	err := ScanCompressedLogs(path, nil, func(entry map[string]interface{}) error {
+		if entry["index"] != float64(count) {
+			t.Errorf("expected index %d, got %v", count, entry["index"])
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to scan logs: %v", err)
+	}
+	if count != 10 {
+		t.Errorf("expected 10 entries, got %d", count)
+	}
+}
+
+func TestScanCompressedLogsStop(t *testing.T) {
+	path := writeCompressedLogs(t, 10)
+
+	var count int
+	err := ScanCompressedLogs(path, nil, func(entry map[string]interface{}) error {
+		count++
+		if count == 3 {
+			return ErrStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ErrStopScan should not be reported: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected scan to stop after 3 entries, got %d", count)
+	}
+
+	callbackErr := errors.New("callback failed")
+	err = ScanCompressedLogs(path, nil, func(entry map[string]interface{}) error {
+		return callbackErr
+	})
+	if !errors.Is(err, callbackErr) {
+		t.Errorf("expected callback error, got %v", err)
+	}
+}
+
+func TestReaderNext(t *testing.T) {
+	path := writeCompressedLogs(t, 5)
+
+	filter := func(log map[string]interface{}) bool {
+		return log["index"].(float64) >= 3
+	}
+
+	reader, err := OpenCompressedLogs(path, filter)
+	if err != nil {
+		t.Fatalf("failed to open logs: %v", err)
+	}
+	defer reader.Close()
+
+	var messages []string
+	for reader.Next() {
+		messages = append(messages, reader.Entry()["message"].(string))
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("unexpected reader error: %v", err)
+	}
+
+	if strings.Join(messages, ",") != "message 3,message 4" {
+		t.Errorf("unexpected messages: %v", messages)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	input := `{"level":"info","message":"a"}
+{"level":"error","message":"b"}
+`
+	reader := NewReader(strings.NewReader(input), FilterByLevel("error"))
+
+	if !reader.Next() || reader.Entry()["message"] != "b" {
+		t.Fatalf("expected entry b, got %v", reader.Entry())
+	}
+	if reader.Next() {
+		t.Error("expected end of stream")
+	}
+}
+
+func TestReaderEpochNanos(t *testing.T) {
+	schema, err := OTelPreset.Schema()
+	if err != nil {
+		t.Fatalf("failed to get schema: %v", err)
+	}
+	input := `{"Timestamp":1792134022951974745,"Body":"exact","Attributes":{"n":1.5,"list":[2]}}
+{"Timestamp":1792134022951974745,"Body":"trailing"} {}
+`
+	var malformed []*MalformedLineError
+	reader := NewReader(strings.NewReader(input), nil)
+	reader.Schema = schema
+	reader.OnMalformed = func(err *MalformedLineError) { malformed = append(malformed, err) }
+
+	if !reader.Next() {
+		t.Fatalf("expected an entry, err: %v", reader.Err())
+	}
+	if nanos := reader.LogEntry().Time.UnixNano(); nanos != 1792134022951974745 {
+		t.Errorf("timestamp read as %d", nanos)
+	}
+	// Other numbers stay float64, as with the default schema
+	attributes := reader.Entry()["Attributes"].(map[string]interface{})
+	if attributes["n"] != 1.5 || attributes["list"].([]interface{})[0] != float64(2) {
+		t.Errorf("unexpected attributes: %#v", attributes)
+	}
+
+	if reader.Next() || len(malformed) != 1 {
+		t.Errorf("data after the object should make the line malformed: %v", malformed)
+	}
+}
+
+const mixedInput = `{"level":"info","message":"first"}
+{"level":"info","message":"trunc
+not json at all
+
+{"level":"error","message":"second"}
+[1,2,3]
+{"level":"warn","message":"third"}`
+
+func TestReaderMalformedLines(t *testing.T) {
+	reader := NewReader(strings.NewReader(mixedInput), nil)
+
+	var malformed []*MalformedLineError
+	reader.OnMalformed = func(err *MalformedLineError) {
+		malformed = append(malformed, err)
+	}
+
+	var messages []string
+	for reader.Next() {
+		messages = append(messages, reader.Entry()["message"].(string))
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("unexpected reader error: %v", err)
+	}
+
+	if strings.Join(messages, ",") != "first,second,third" {
+		t.Errorf("expected reading to resume after bad lines, got %v", messages)
+	}
+
+	if len(malformed) != 3 {
+		t.Fatalf("expected 3 malformed lines, got %d", len(malformed))
+	}
+	wantLines := []int{2, 3, 6}
+	for i, err := range malformed {
+		if err.Line != wantLines[i] {
+			t.Errorf("expected malformed line %d, got %d", wantLines[i], err.Line)
+		}
+	}
+	if string(malformed[1].Raw) != "not json at all" {
+		t.Errorf("unexpected raw bytes: %q", malformed[1].Raw)
+	}
+}
+
+func TestReaderStrict(t *testing.T) {
+	reader := NewReader(strings.NewReader(mixedInput), nil)
+	reader.Strict = true
+
+	var count int
+	for reader.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("expected 1 entry before the corrupt record, got %d", count)
+	}
+
+	var malformed *MalformedLineError
+	if !errors.As(reader.Err(), &malformed) {
+		t.Fatalf("expected MalformedLineError, got %v", reader.Err())
+	}
+	if malformed.Line != 2 {
+		t.Errorf("expected line 2, got %d", malformed.Line)
+	}
+}
+
+func TestReaderLongLine(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	input := `{"message":"` + long + `"}` + "\n" + `{"message":"short"}`
+
+	reader := NewReader(strings.NewReader(input), nil)
+	var count int
+	for reader.Next() {
+		count++
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("unexpected reader error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 entries, got %d", count)
+	}
+}
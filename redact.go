@@ -0,0 +1,362 @@
+package jsonlog
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactAction selects how sensitive values are replaced
+type RedactAction string
+
+const (
+	RedactMask RedactAction = "mask" // replaced with RedactionConfig.Mask
+	RedactHash RedactAction = "hash" // replaced with "sha256:" and a short digest
+)
+
+// Patterns for common sensitive values, for use in RedactionConfig.Patterns
+var (
+	CreditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	EmailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	JWTPattern        = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// defaultMask replaces redacted values when RedactionConfig.Mask is empty
+const defaultMask = "[REDACTED]"
+
+// RedactionConfig holds the redaction rules
+type RedactionConfig struct {
+	// Keys are field names whose whole value is redacted, matched
+	// case-insensitively at any depth of nested objects
+	Keys []string
+
+	// Patterns are matched against string values (and the message); each
+	// match is redacted
+	Patterns []*regexp.Regexp
+
+	// Action is how values are replaced (empty = RedactMask)
+	Action RedactAction
+
+	// Mask is the replacement text for RedactMask (empty = "[REDACTED]")
+	Mask string
+
+	// HashKey makes RedactHash use HMAC-SHA256 with this key instead of
+	// plain SHA-256, so short values cannot be recovered by guessing
+	HashKey []byte
+}
+
+// DefaultRedactionConfig returns rules for common credentials, card
+// numbers, email addresses and JWTs
+func DefaultRedactionConfig() RedactionConfig {
+	return RedactionConfig{
+		Keys: []string{
+			"password", "passwd", "secret", "token", "access_token", "refresh_token",
+			"api_key", "apikey", "authorization", "cookie", "set-cookie",
+		},
+		Patterns: []*regexp.Regexp{CreditCardPattern, EmailPattern, JWTPattern},
+	}
+}
+
+// Redactor applies redaction rules to fields and records
+type Redactor struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+	action   RedactAction
+	mask     string
+	hashKey  []byte
+}
+
+// NewRedactor validates the rules and builds a Redactor
+func NewRedactor(config RedactionConfig) (*Redactor, error) {
+	r := &Redactor{
+		keys:     make(map[string]bool, len(config.Keys)),
+		patterns: config.Patterns,
+		action:   config.Action,
+		mask:     config.Mask,
+		hashKey:  config.HashKey,
+	}
+
+	switch r.action {
+	case "":
+		r.action = RedactMask
+	case RedactMask, RedactHash:
+	default:
+		return nil, fmt.Errorf("unknown action %q", r.action)
+	}
+	if r.mask == "" {
+		r.mask = defaultMask
+	}
+
+	for _, key := range config.Keys {
+		if key == "" {
+			return nil, fmt.Errorf("empty key")
+		}
+		r.keys[strings.ToLower(key)] = true
+	}
+	for _, pattern := range r.patterns {
+		if pattern == nil {
+			return nil, fmt.Errorf("nil pattern")
+		}
+	}
+
+	return r, nil
+}
+
+// replace returns the replacement for a sensitive value
+func (r *Redactor) replace(value string) string {
+	if r.action == RedactMask {
+		return r.mask
+	}
+
+	var sum []byte
+	if len(r.hashKey) > 0 {
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(value))
+		sum = mac.Sum(nil)
+	} else {
+		digest := sha256.Sum256([]byte(value))
+		sum = digest[:]
+	}
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// isSensitiveKey reports whether a key's whole value is redacted
+func (r *Redactor) isSensitiveKey(key string) bool {
+	return r.keys[strings.ToLower(key)]
+}
+
+// String redacts the pattern matches in s
+func (r *Redactor) String(s string) string {
+	redacted, _ := r.redactString(s)
+	return redacted
+}
+
+func (r *Redactor) redactString(s string) (string, bool) {
+	changed := false
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			changed = true
+			return r.replace(match)
+		})
+	}
+	return s, changed
+}
+
+// Map returns a copy of a decoded record with the rules applied at every
+// depth. The input is not modified.
+func (r *Redactor) Map(log map[string]interface{}) map[string]interface{} {
+	redacted, _ := r.redactValue(log)
+	return redacted.(map[string]interface{})
+}
+
+// redactValue redacts a decoded JSON value. Unchanged values are returned
+// as they are; changed maps and slices are copies.
+func (r *Redactor) redactValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, item := range v {
+			var redacted interface{}
+			var changed bool
+			if r.isSensitiveKey(key) {
+				redacted, changed = r.replace(valueText(item)), true
+			} else {
+				redacted, changed = r.redactValue(item)
+			}
+			if changed && out == nil {
+				out = make(map[string]interface{}, len(v))
+				for k, item := range v {
+					out[k] = item
+				}
+			}
+			if changed {
+				out[key] = redacted
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+
+	case []interface{}:
+		var out []interface{}
+		for i, item := range v {
+			redacted, changed := r.redactValue(item)
+			if changed && out == nil {
+				out = append([]interface{}(nil), v...)
+			}
+			if changed {
+				out[i] = redacted
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	}
+
+	return value, false
+}
+
+// valueText returns the text a sensitive value is hashed from
+func valueText(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// Fields returns the fields with the rules applied. Fields that need no
+// change are returned as they are, keeping their original encoding.
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fields {
+		redacted, changed := r.redactField(field)
+		if changed && out == nil {
+			out = append([]zapcore.Field(nil), fields...)
+		}
+		if changed {
+			out[i] = redacted
+		}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (r *Redactor) redactField(field zapcore.Field) (zapcore.Field, bool) {
+	switch field.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return field, false
+	}
+
+	if r.isSensitiveKey(field.Key) {
+		return zap.String(field.Key, r.replace(valueText(fieldValue(field)))), true
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		if s, changed := r.redactString(field.String); changed {
+			return zap.String(field.Key, s), true
+		}
+		return field, false
+
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType, zapcore.ArrayMarshalerType,
+		zapcore.ReflectType, zapcore.StringerType, zapcore.ErrorType, zapcore.ByteStringType:
+		redacted, changed := r.redactValue(fieldValue(field))
+		if !changed {
+			return field, false
+		}
+		if field.Type == zapcore.InlineMarshalerType {
+			if object, ok := redacted.(map[string]interface{}); ok {
+				return zap.Inline(redactedObject(object)), true
+			}
+		}
+		if s, ok := redacted.(string); ok {
+			return zap.String(field.Key, s), true
+		}
+		return zap.Reflect(field.Key, redacted), true
+	}
+
+	// Numbers, booleans, times and durations carry no free text
+	return field, false
+}
+
+// fieldValue returns the value of a field as decoded JSON would hold it, so
+// nested objects can be walked
+func fieldValue(field zapcore.Field) interface{} {
+	switch field.Type {
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			return err.Error()
+		}
+	case zapcore.InlineMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if marshaler, ok := field.Interface.(zapcore.ObjectMarshaler); ok {
+			if err := marshaler.MarshalLogObject(enc); err == nil {
+				return normalizeValue(enc.Fields)
+			}
+		}
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	return normalizeValue(enc.Fields[field.Key])
+}
+
+// normalizeValue converts a value to the maps, slices and scalars that
+// encoding/json decodes into, keeping numbers exact
+func normalizeValue(value interface{}) interface{} {
+	switch value.(type) {
+	case string, nil:
+		return value
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(data)
+	}
+	return decoded
+}
+
+// redactedObject writes a redacted map back as inline fields
+type redactedObject map[string]interface{}
+
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for key, value := range o {
+		if err := enc.AddReflected(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wrap returns a core that redacts entries before passing them to core
+func (r *Redactor) Wrap(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core, redactor: r}
+}
+
+// redactCore applies a Redactor to context fields, entry fields and the
+// message before they reach the wrapped core
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.Fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.String(ent.Message)
+	return c.Core.Write(ent, c.redactor.Fields(fields))
+}
@@ -0,0 +1,201 @@
+package jsonlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testCredentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func (c testCredentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", c.User)
+	enc.AddString("password", c.Password)
+	return nil
+}
+
+func TestRedactorMap(t *testing.T) {
+	redactor, err := NewRedactor(DefaultRedactionConfig())
+	if err != nil {
+		t.Fatalf("failed to create redactor: %v", err)
+	}
+
+	log := map[string]interface{}{
+		"message":  "charge for bob@example.com",
+		"Password": "hunter2",
+		"request": map[string]interface{}{
+			"headers": map[string]interface{}{"Authorization": "Bearer abc"},
+			"card":    "4111 1111 1111 1111",
+			"items":   []interface{}{"ok", "jwt eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig"},
+		},
+		"count": float64(3),
+	}
+	redacted := redactor.Map(log)
+
+	if redacted["Password"] != defaultMask {
+		t.Errorf("Password = %v", redacted["Password"])
+	}
+	if redacted["message"] != "charge for "+defaultMask {
+		t.Errorf("message = %v", redacted["message"])
+	}
+	if redacted["count"] != float64(3) {
+		t.Errorf("count = %v", redacted["count"])
+	}
+	request := redacted["request"].(map[string]interface{})
+	if got := request["headers"].(map[string]interface{})["Authorization"]; got != defaultMask {
+		t.Errorf("Authorization = %v", got)
+	}
+	if request["card"] != defaultMask {
+		t.Errorf("card = %v", request["card"])
+	}
+	if got := request["items"].([]interface{})[1]; got != "jwt "+defaultMask {
+		t.Errorf("items[1] = %v", got)
+	}
+
+	// The input is left untouched
+	if log["Password"] != "hunter2" || log["request"].(map[string]interface{})["card"] != "4111 1111 1111 1111" {
+		t.Error("Map modified its input")
+	}
+}
+
+func TestRedactorHash(t *testing.T) {
+	redactor, err := NewRedactor(RedactionConfig{Keys: []string{"token"}, Action: RedactHash})
+	if err != nil {
+		t.Fatalf("failed to create redactor: %v", err)
+	}
+	keyed, err := NewRedactor(RedactionConfig{Keys: []string{"token"}, Action: RedactHash, HashKey: []byte("k")})
+	if err != nil {
+		t.Fatalf("failed to create redactor: %v", err)
+	}
+
+	first := redactor.Map(map[string]interface{}{"token": "abc"})["token"].(string)
+	second := redactor.Map(map[string]interface{}{"token": "abc"})["token"].(string)
+	other := redactor.Map(map[string]interface{}{"token": "abd"})["token"].(string)
+	withKey := keyed.Map(map[string]interface{}{"token": "abc"})["token"].(string)
+
+	if !strings.HasPrefix(first, "sha256:") || first != second {
+		t.Errorf("hash should be stable: %q, %q", first, second)
+	}
+	if first == other || first == withKey {
+		t.Errorf("hashes should differ: %q, %q, %q", first, other, withKey)
+	}
+}
+
+func TestRedactorFields(t *testing.T) {
+	redactor, err := NewRedactor(DefaultRedactionConfig())
+	if err != nil {
+		t.Fatalf("failed to create redactor: %v", err)
+	}
+
+	fields := []zap.Field{
+		zap.Int("count", 1),
+		zap.String("email", "bob@example.com"),
+		zap.Object("login", testCredentials{User: "bob", Password: "hunter2"}),
+		zap.Any("body", map[string]interface{}{"token": "t", "id": 12345678901234}),
+		zap.Error(errors.New("lookup alice@example.com failed")),
+		zap.Inline(testCredentials{User: "carol", Password: "pw"}),
+	}
+	redacted := redactor.Fields(fields)
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range redacted {
+		field.AddTo(enc)
+	}
+
+	if enc.Fields["count"] != int64(1) {
+		t.Errorf("count = %v", enc.Fields["count"])
+	}
+	if enc.Fields["email"] != defaultMask {
+		t.Errorf("email = %v", enc.Fields["email"])
+	}
+	if enc.Fields["error"] != "lookup "+defaultMask+" failed" {
+		t.Errorf("error = %v", enc.Fields["error"])
+	}
+	if enc.Fields["password"] != defaultMask || enc.Fields["user"] != "carol" {
+		t.Errorf("inline fields = %v, %v", enc.Fields["user"], enc.Fields["password"])
+	}
+	if fields[1].String != "bob@example.com" {
+		t.Error("Fields modified its input")
+	}
+
+	unchanged := []zap.Field{zap.String("user", "bob"), zap.Int("n", 2)}
+	if got := redactor.Fields(unchanged); &got[0] != &unchanged[0] {
+		t.Error("unchanged fields should be returned as they are")
+	}
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Redaction:   &RedactionConfig{Keys: []string{"password", "token"}, Patterns: []*regexp.Regexp{EmailPattern}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.With(zap.String("token", "secret-token")).Info("signup bob@example.com",
+		zap.Object("login", testCredentials{User: "bob", Password: "hunter2"}),
+		zap.Reflect("body", testCredentials{User: "bob", Password: "hunter2"}),
+	)
+	logger.Close()
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	for _, secret := range []string{"secret-token", "hunter2", "bob@example.com"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("%q was written: %s", secret, content)
+		}
+	}
+
+	entries, err := ReadLogEntries(filepath.Join(tmpDir, "test.log"), nil)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("failed to read entries: %v, %d", err, len(entries))
+	}
+	entry := entries[0]
+	if entry.Message != "signup "+defaultMask {
+		t.Errorf("message = %q", entry.Message)
+	}
+	if got, _ := entry.String("login.user"); got != "bob" {
+		t.Errorf("login.user = %q", got)
+	}
+	if got, _ := entry.String("body.password"); got != defaultMask {
+		t.Errorf("body.password = %q", got)
+	}
+}
+
+func TestRedactionValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RedactionConfig
+	}{
+		{"unknown action", RedactionConfig{Action: "drop"}},
+		{"empty key", RedactionConfig{Keys: []string{""}}},
+		{"nil pattern", RedactionConfig{Patterns: []*regexp.Regexp{nil}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRedactor(tt.config); err == nil {
+				t.Error("expected an error")
+			}
+			config := tt.config
+			if _, err := NewLogger(Config{LogPath: t.TempDir(), Redaction: &config}); err == nil {
+				t.Error("expected an error from NewLogger")
+			}
+		})
+	}
+}
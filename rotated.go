@@ -0,0 +1,200 @@
+package jsonlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedCompressor gzips the backups lumberjack leaves behind when it
+// rotates the log file. Lumberjack's own Compress option drops its errors
+// and cannot be waited for, so CompressRotated is done here instead: in the
+// background after each rotation, and once more on close, which waits for
+// the background work and returns every failure.
+type rotatedCompressor struct {
+	dir      string
+	baseName string
+	maxSize  int64
+
+	writeMu sync.Mutex
+	out     io.Writer // the lumberjack logger
+	size    int64     // bytes in the active file, as lumberjack counts them
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	mu   sync.Mutex
+	errs []error
+}
+
+func newRotatedCompressor(out io.Writer, filePath string, maxSize int64) *rotatedCompressor {
+	c := &rotatedCompressor{
+		out:      out,
+		dir:      filepath.Dir(filePath),
+		baseName: strings.TrimSuffix(filepath.Base(filePath), ".log"),
+		maxSize:  maxSize,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		c.size = info.Size()
+	}
+	return c
+}
+
+// start compresses backups left by an earlier run, then waits for rotations
+func (c *rotatedCompressor) start() {
+	c.wake <- struct{}{}
+	go c.run()
+}
+
+func (c *rotatedCompressor) run() {
+	defer close(c.stopped)
+	for {
+		select {
+		case <-c.wake:
+			c.record(c.compressBackups())
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Write writes to the log file and wakes the compressor when the write made
+// lumberjack rotate. Lumberjack rotates before a write that would take the
+// file past its maximum size.
+func (c *rotatedCompressor) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	rotated := c.size+int64(len(p)) > c.maxSize
+	if rotated {
+		c.size = 0
+	}
+	n, err := c.out.Write(p)
+	c.size += int64(n)
+
+	if rotated {
+		select {
+		case c.wake <- struct{}{}:
+		default: // A pass is already pending
+		}
+	}
+	return n, err
+}
+
+func (c *rotatedCompressor) record(err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	c.errs = append(c.errs, err)
+	c.mu.Unlock()
+}
+
+// close waits for the background pass, compresses any backup still left and
+// returns the errors of every pass since the logger was created
+func (c *rotatedCompressor) close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.done)
+		<-c.stopped
+		c.record(c.compressBackups())
+
+		c.mu.Lock()
+		err = errors.Join(c.errs...)
+		c.mu.Unlock()
+	})
+	return err
+}
+
+// compressBackups gzips every uncompressed backup of the log and removes
+// the original. Backups removed meanwhile by MaxBackups or MaxAge are
+// skipped.
+func (c *rotatedCompressor) compressBackups() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var errs []error
+	prefix := c.baseName + "-"
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		stamp := strings.TrimSuffix(name[len(prefix):], ".log")
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue // Not a lumberjack backup of this log
+		}
+
+		path := filepath.Join(c.dir, name)
+		if err := compressBackup(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("failed to compress rotated file %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compressBackup replaces path with path.gz. The archive is written under a
+// temporary name first, so a failure never leaves a truncated .gz behind.
+func compressBackup(path string) error {
+	tmpPath := path + ".gz.tmp"
+	if err := gzipFile(path, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path+".gz"); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// gzipFile writes a gzip copy of sourcePath to destinationPath
+func gzipFile(sourcePath, destinationPath string) (err error) {
+	// Open source file
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer source.Close()
+
+	// Create destination file
+	destination, err := os.Create(destinationPath)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed file: %w", err)
+	}
+	defer func() {
+		if closeErr := destination.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close compressed file: %w", closeErr)
+		}
+	}()
+
+	// Create gzip writer
+	gzipWriter := gzip.NewWriter(destination)
+
+	// Copy content
+	if _, err := io.Copy(gzipWriter, source); err != nil {
+		gzipWriter.Close()
+		return fmt.Errorf("failed to compress: %w", err)
+	}
+
+	// Close gzip writer to write the footer
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package jsonlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatedCompressor(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	// A backup left by an earlier run, and files that are not backups
+	writeFile("app-2025-12-02T10-00-00.000.log", `{"message":"old"}`+"\n")
+	writeFile("app-notes.log", "not a backup\n")
+	writeFile("other-2025-12-02T10-00-00.000.log", "another log\n")
+
+	c := newRotatedCompressor(&syncBuffer{}, filepath.Join(tmpDir, "app.log"), 10)
+	c.start()
+
+	// A write past the maximum size is a rotation
+	c.Write([]byte("0123456"))
+	writeFile("app-2025-12-02T10-00-01.000.log", `{"message":"rotated"}`+"\n")
+	c.Write([]byte("0123456"))
+
+	if err := c.close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	for _, name := range []string{"app-2025-12-02T10-00-00.000.log", "app-2025-12-02T10-00-01.000.log"} {
+		logs, err := ReadCompressedLogs(filepath.Join(tmpDir, name+".gz"))
+		if err != nil || len(logs) != 1 {
+			t.Errorf("%s: expected a compressed backup, got %v, %v", name, logs, err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s: the uncompressed backup should be removed", name)
+		}
+	}
+	for _, name := range []string{"app-notes.log", "other-2025-12-02T10-00-00.000.log"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("%s should be left alone: %v", name, err)
+		}
+	}
+}
+
+func TestRotatedCompressorError(t *testing.T) {
+	tmpDir := t.TempDir()
+	// A recent backup, so that lumberjack does not remove it for its age
+	name := "app-" + time.Now().UTC().Format(backupTimeFormat) + ".log"
+	backup := filepath.Join(tmpDir, name)
+	if err := os.WriteFile(backup, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+	// The temporary archive cannot be created over a directory
+	if err := os.MkdirAll(filepath.Join(backup+".gz.tmp", "blocker"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	logger, err := NewLogger(Config{
+		LogPath:         tmpDir,
+		LogFileName:     "app",
+		CompressRotated: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Info("message")
+
+	err = logger.Close()
+	if err == nil || !strings.Contains(err.Error(), "failed to compress rotated file "+name) {
+		t.Fatalf("Close should return the compression error, got %v", err)
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Errorf("the backup should be kept when it cannot be compressed: %v", err)
+	}
+}
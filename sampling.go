@@ -0,0 +1,282 @@
+package jsonlog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig limits repeated entries zap-style: of the entries with the
+// same level and message in each Tick, the first First are written and
+// then every Thereafter-th
+type SamplingConfig struct {
+	// Tick is the sampling interval (0 = 1 second)
+	Tick time.Duration
+
+	// First is the number of entries written per tick before sampling
+	First int
+
+	// Thereafter writes every Nth entry after First (0 = drop the rest of
+	// the tick)
+	Thereafter int
+}
+
+// RateLimitConfig limits entries per message with a token bucket. Entries
+// are keyed by level and message.
+type RateLimitConfig struct {
+	// Rate is the sustained number of entries per second per message
+	Rate float64
+
+	// Burst is the bucket size (0 = Rate rounded up, at least 1)
+	Burst int
+}
+
+const (
+	defaultSamplingTick        = time.Second
+	defaultDropSummaryInterval = time.Minute
+
+	// maxDroppedMessages bounds the per-message counts kept between
+	// summaries; further messages only count towards the totals
+	maxDroppedMessages = 100
+)
+
+// DropSummaryMessage is the message of the summary records written for
+// entries dropped by sampling and rate limiting
+const DropSummaryMessage = "log entries dropped"
+
+// newSamplingCore wraps core in the sampler described by config
+func newSamplingCore(core zapcore.Core, config SamplingConfig, stats *dropStats) (zapcore.Core, error) {
+	if config.Tick < 0 {
+		return nil, fmt.Errorf("Tick must not be negative, got %s", config.Tick)
+	}
+	if config.Tick == 0 {
+		config.Tick = defaultSamplingTick
+	}
+	if config.First < 1 {
+		return nil, fmt.Errorf("First must be at least 1, got %d", config.First)
+	}
+	if config.Thereafter < 0 {
+		return nil, fmt.Errorf("Thereafter must not be negative, got %d", config.Thereafter)
+	}
+
+	hook := zapcore.SamplerHook(func(ent zapcore.Entry, decision zapcore.SamplingDecision) {
+		if decision&zapcore.LogDropped != 0 {
+			stats.add(&stats.sampled, ent)
+		}
+	})
+	return zapcore.NewSamplerWithOptions(core, config.Tick, config.First, config.Thereafter, hook), nil
+}
+
+// tokenBucket holds the tokens left for one message
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter holds the buckets shared by a rate-limited core and its
+// children
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+func newRateLimiter(config RateLimitConfig) (*rateLimiter, error) {
+	if config.Rate <= 0 {
+		return nil, fmt.Errorf("Rate must be positive, got %g", config.Rate)
+	}
+	if config.Burst < 0 {
+		return nil, fmt.Errorf("Burst must not be negative, got %d", config.Burst)
+	}
+
+	burst := float64(config.Burst)
+	if burst == 0 {
+		burst = float64(int(config.Rate))
+		if burst < config.Rate {
+			burst++
+		}
+	}
+
+	return &rateLimiter{
+		rate:    config.Rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}, nil
+}
+
+// allow takes a token from the bucket of key if one is left
+func (r *rateLimiter) allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	bucket, ok := r.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: r.burst, last: now}
+		r.buckets[key] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * r.rate
+	if bucket.tokens > r.burst {
+		bucket.tokens = r.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// prune forgets buckets that have refilled, which behave like new ones
+func (r *rateLimiter) prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	full := time.Duration(r.burst / r.rate * float64(time.Second))
+	now := r.now()
+	for key, bucket := range r.buckets {
+		if now.Sub(bucket.last) >= full {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+// rateLimitCore drops entries whose message has run out of tokens
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *rateLimiter
+	stats   *dropStats
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter, stats: c.stats}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if !c.limiter.allow(ent.Level.String() + "\x00" + ent.Message) {
+		c.stats.add(&c.stats.rateLimited, ent)
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// dropStats counts dropped entries and writes them out as summary records
+// every interval, straight to the outputs so that summaries are never
+// dropped themselves
+type dropStats struct {
+	mu          sync.Mutex
+	sampled     int64
+	rateLimited int64
+	messages    map[string]int64
+
+	core    zapcore.Core
+	limiter *rateLimiter
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newDropStats(core zapcore.Core) *dropStats {
+	return &dropStats{
+		messages: make(map[string]int64),
+		core:     core,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// add counts a dropped entry in counter
+func (s *dropStats) add(counter *int64, ent zapcore.Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	*counter++
+	if _, ok := s.messages[ent.Message]; ok || len(s.messages) < maxDroppedMessages {
+		s.messages[ent.Message]++
+	}
+}
+
+// start writes a summary every interval until close is called
+func (s *dropStats) start(interval time.Duration) {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.flush()
+				if s.limiter != nil {
+					s.limiter.prune()
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the summaries and writes the remaining counts
+func (s *dropStats) close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.stop)
+		<-s.done
+		err = s.flush()
+	})
+	return err
+}
+
+// flush writes a summary record if anything was dropped since the last one
+func (s *dropStats) flush() error {
+	s.mu.Lock()
+	sampled, rateLimited, messages := s.sampled, s.rateLimited, s.messages
+	s.sampled, s.rateLimited = 0, 0
+	if len(messages) > 0 {
+		s.messages = make(map[string]int64)
+	}
+	s.mu.Unlock()
+
+	if sampled == 0 && rateLimited == 0 {
+		return nil
+	}
+
+	ent := zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now(), Message: DropSummaryMessage}
+	if !s.core.Enabled(ent.Level) {
+		return nil
+	}
+	return s.core.Write(ent, []zapcore.Field{
+		zap.Int64("dropped_sampled", sampled),
+		zap.Int64("dropped_rate_limited", rateLimited),
+		zap.Object("dropped_messages", droppedMessages(messages)),
+	})
+}
+
+// droppedMessages writes per-message drop counts in message order
+type droppedMessages map[string]int64
+
+func (m droppedMessages) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	messages := make([]string, 0, len(m))
+	for message := range m {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+
+	for _, message := range messages {
+		enc.AddInt64(message, m[message])
+	}
+	return nil
+}
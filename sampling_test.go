@@ -0,0 +1,206 @@
+package jsonlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countMessages counts the records of a log file by message
+func countMessages(t *testing.T, path string) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for _, record := range readRecords(t, path) {
+		message, _ := record["message"].(string)
+		counts[message]++
+	}
+	return counts
+}
+
+// dropSummary returns the last summary record of a log file
+func dropSummary(t *testing.T, path string) LogEntry {
+	t.Helper()
+	entries, err := ReadLogEntries(path, FilterByLevel("warn"))
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Message == DropSummaryMessage {
+			return entries[i]
+		}
+	}
+	t.Fatalf("no summary record in %s", path)
+	return LogEntry{}
+}
+
+func TestSamplingWithTeedOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Capture the console output in a file
+	consolePath := filepath.Join(tmpDir, "console.log")
+	console, err := os.Create(consolePath)
+	if err != nil {
+		t.Fatalf("failed to create console file: %v", err)
+	}
+	defer console.Close()
+	stdout := os.Stdout
+	os.Stdout = console
+	defer func() { os.Stdout = stdout }()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Console:     &OutputConfig{Encoding: JSONEncoding},
+		Sampling:    &SamplingConfig{Tick: time.Hour, First: 3, Thereafter: 4},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	for i := 0; i < 11; i++ {
+		logger.Error("database unavailable")
+	}
+	logger.Info("other message")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	// First 3, then the 7th and 11th; the summary counts the other 6
+	for _, path := range []string{filepath.Join(tmpDir, "test.log"), consolePath} {
+		counts := countMessages(t, path)
+		if counts["database unavailable"] != 5 || counts["other message"] != 1 || counts[DropSummaryMessage] != 1 {
+			t.Errorf("%s: unexpected counts %v", filepath.Base(path), counts)
+		}
+
+		summary := dropSummary(t, path)
+		if n, _ := summary.Int64("dropped_sampled"); n != 6 {
+			t.Errorf("dropped_sampled = %d", n)
+		}
+		if n, _ := summary.Int64("dropped_rate_limited"); n != 0 {
+			t.Errorf("dropped_rate_limited = %d", n)
+		}
+		if n, _ := summary.Int64("dropped_messages.database unavailable"); n != 6 {
+			t.Errorf("dropped_messages = %v", summary.Fields["dropped_messages"])
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		RateLimit:   &RateLimitConfig{Rate: 0.001, Burst: 2},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	child := logger.With()
+	for i := 0; i < 5; i++ {
+		logger.Warn("slow request")
+		child.Warn("slow request")
+	}
+	logger.Error("slow request") // keyed by level too
+	logger.Close()
+
+	path := filepath.Join(tmpDir, "test.log")
+	counts := countMessages(t, path)
+	if counts["slow request"] != 3 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+	summary := dropSummary(t, path)
+	if n, _ := summary.Int64("dropped_rate_limited"); n != 8 {
+		t.Errorf("dropped_rate_limited = %d", n)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	limiter, err := newRateLimiter(RateLimitConfig{Rate: 2})
+	if err != nil {
+		t.Fatalf("failed to create rate limiter: %v", err)
+	}
+	now := time.Unix(0, 0)
+	limiter.now = func() time.Time { return now }
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if limiter.allow("a") {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("expected a burst of 2, got %d", allowed)
+	}
+	if !limiter.allow("b") {
+		t.Error("keys should have separate buckets")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if !limiter.allow("a") || limiter.allow("a") {
+		t.Error("expected one token after half a second")
+	}
+
+	now = now.Add(time.Hour)
+	limiter.prune()
+	if len(limiter.buckets) != 0 {
+		t.Errorf("refilled buckets should be pruned, got %d", len(limiter.buckets))
+	}
+}
+
+func TestDropSummaryInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	logger, err := NewLogger(Config{
+		LogPath:             tmpDir,
+		LogFileName:         "test",
+		Sampling:            &SamplingConfig{Tick: time.Hour, First: 1},
+		DropSummaryInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("tick")
+	logger.Info("tick")
+
+	path := filepath.Join(tmpDir, "test.log")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, err := os.ReadFile(path)
+		if err == nil && strings.Contains(string(content), DropSummaryMessage) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("summary was not written before Close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSamplingValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"zero first", Config{Sampling: &SamplingConfig{}}},
+		{"negative tick", Config{Sampling: &SamplingConfig{First: 1, Tick: -time.Second}}},
+		{"negative thereafter", Config{Sampling: &SamplingConfig{First: 1, Thereafter: -1}}},
+		{"zero rate", Config{RateLimit: &RateLimitConfig{}}},
+		{"negative burst", Config{RateLimit: &RateLimitConfig{Rate: 1, Burst: -1}}},
+		{"negative interval", Config{RateLimit: &RateLimitConfig{Rate: 1}, DropSummaryInterval: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LogPath = t.TempDir()
+			if _, err := NewLogger(tt.config); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TimeEncoding selects how timestamps are written
+type TimeEncoding string
+
+const (
+	ISO8601TimeEncoding     TimeEncoding = "iso8601"      // 2025-12-02T15:59:57.317+0800
+	RFC3339NanoTimeEncoding TimeEncoding = "rfc3339nano"  // 2025-12-02T15:59:57.317123456+08:00
+	EpochMillisTimeEncoding TimeEncoding = "epoch_millis" // 1764662397317.123
+	EpochNanosTimeEncoding  TimeEncoding = "epoch_nanos"  // 1764662397317123456
+)
+
+// LevelEncoding selects how level names are written
+type LevelEncoding string
+
+const (
+	LowercaseLevelEncoding LevelEncoding = "lowercase" // info
+	UppercaseLevelEncoding LevelEncoding = "uppercase" // INFO
+)
+
+// Schema describes the keys and formats of a log record. The logger writes
+// records with it and the filters and readers built from the same Schema
+// read them back. Empty fields take the DefaultSchema values.
+type Schema struct {
+	TimeKey       string
+	LevelKey      string
+	MessageKey    string
+	CallerKey     string
+	StacktraceKey string
+	NameKey       string
+
+	TimeEncoding  TimeEncoding
+	LevelEncoding LevelEncoding
+
+	// preset is set on schemas returned by Preset.Schema
+	preset Preset
+}
+
+// DefaultSchema returns the schema used when none is configured
+func DefaultSchema() Schema {
+	return Schema{
+		TimeKey:       "timestamp",
+		LevelKey:      "level",
+		MessageKey:    "message",
+		CallerKey:     "caller",
+		StacktraceKey: "stacktrace",
+		NameKey:       "logger",
+		TimeEncoding:  ISO8601TimeEncoding,
+		LevelEncoding: LowercaseLevelEncoding,
+	}
+}
+
+// withDefaults fills empty fields from DefaultSchema
+func (s Schema) withDefaults() Schema {
+	defaults := DefaultSchema()
+	setDefault := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	setDefault(&s.TimeKey, defaults.TimeKey)
+	setDefault(&s.LevelKey, defaults.LevelKey)
+	setDefault(&s.MessageKey, defaults.MessageKey)
+	setDefault(&s.CallerKey, defaults.CallerKey)
+	setDefault(&s.StacktraceKey, defaults.StacktraceKey)
+	setDefault(&s.NameKey, defaults.NameKey)
+	if s.TimeEncoding == "" {
+		s.TimeEncoding = defaults.TimeEncoding
+	}
+	if s.LevelEncoding == "" {
+		s.LevelEncoding = defaults.LevelEncoding
+	}
+	return s
+}
+
+// validate checks the encodings and that no two standard keys collide
+func (s Schema) validate() error {
+	switch s.TimeEncoding {
+	case ISO8601TimeEncoding, RFC3339NanoTimeEncoding, EpochMillisTimeEncoding, EpochNanosTimeEncoding:
+	default:
+		return fmt.Errorf("unknown time encoding %q", s.TimeEncoding)
+	}
+
+	switch s.LevelEncoding {
+	case LowercaseLevelEncoding, UppercaseLevelEncoding:
+	default:
+		return fmt.Errorf("unknown level encoding %q", s.LevelEncoding)
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range s.keys() {
+		if seen[key] {
+			return fmt.Errorf("key %q is used more than once", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// keys lists the standard keys
+func (s Schema) keys() []string {
+	return []string{s.TimeKey, s.LevelKey, s.MessageKey, s.CallerKey, s.StacktraceKey, s.NameKey}
+}
+
+// encoderConfig builds the zap encoder configuration for the schema
+func (s Schema) encoderConfig() zapcore.EncoderConfig {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        s.TimeKey,
+		LevelKey:       s.LevelKey,
+		NameKey:        s.NameKey,
+		CallerKey:      s.CallerKey,
+		FunctionKey:    zapcore.OmitKey,
+		MessageKey:     s.MessageKey,
+		StacktraceKey:  s.StacktraceKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	switch s.TimeEncoding {
+	case RFC3339NanoTimeEncoding:
+		encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	case EpochMillisTimeEncoding:
+		encoderConfig.EncodeTime = zapcore.EpochMillisTimeEncoder
+	case EpochNanosTimeEncoding:
+		encoderConfig.EncodeTime = zapcore.EpochNanosTimeEncoder
+	}
+
+	if s.LevelEncoding == UppercaseLevelEncoding {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+
+	return encoderConfig
+}
+
+// colorLevelEncoder returns the colored variant of the level encoding
+func (s Schema) colorLevelEncoder() zapcore.LevelEncoder {
+	if s.LevelEncoding == UppercaseLevelEncoding {
+		return zapcore.CapitalColorLevelEncoder
+	}
+	return zapcore.LowercaseColorLevelEncoder
+}
+
+// parseTime reads a timestamp value written with this schema. Strings are
+// accepted in any of the text layouts; numbers are read as epoch millis or
+// nanos according to TimeEncoding. Like entryTime and entryLevel it expects
+// a schema with defaults applied.
+func (s Schema) parseTime(value interface{}) (time.Time, bool) {
+	if ts, ok := value.(string); ok {
+		t, err := parseTimestamp(ts)
+		return t, err == nil
+	}
+
+	// Readers keep epoch nanos as a json.Number, exact where float64 is not
+	if number, ok := value.(json.Number); ok && s.TimeEncoding == EpochNanosTimeEncoding {
+		if nanos, err := number.Int64(); err == nil {
+			return time.Unix(0, nanos), true
+		}
+	}
+
+	n, ok := toFloat(value)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch s.TimeEncoding {
+	case EpochMillisTimeEncoding:
+		return time.UnixMicro(int64(n * 1e3)), true
+	case EpochNanosTimeEncoding:
+		return time.Unix(0, int64(n)), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// entryTime returns the parsed timestamp of a record
+func (s Schema) entryTime(log map[string]interface{}) (time.Time, bool) {
+	value, ok := log[s.TimeKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	return s.parseTime(value)
+}
+
+// entryLevel returns the parsed level of a record
+func (s Schema) entryLevel(log map[string]interface{}) (zapcore.Level, bool) {
+	name, ok := log[s.LevelKey].(string)
+	if !ok {
+		return 0, false
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, false
+	}
+	return level, true
+}
+
+// FilterByLevel creates a filter for a specific log level. Level names
+// compare case-insensitively.
+func (s Schema) FilterByLevel(level string) FilterFunc {
+	key := s.withDefaults().LevelKey
+	return func(log map[string]interface{}) bool {
+		if l, ok := log[key].(string); ok {
+			return strings.EqualFold(l, level)
+		}
+		return false
+	}
+}
+
+// FilterByMinLevel creates a filter for logs at level or above. An unknown
+// level matches nothing.
+func (s Schema) FilterByMinLevel(level LogLevel) FilterFunc {
+	min, err := toZapLevel(level)
+	if err != nil {
+		return func(map[string]interface{}) bool { return false }
+	}
+
+	s = s.withDefaults()
+	return func(log map[string]interface{}) bool {
+		entryLevel, ok := s.entryLevel(log)
+		return ok && entryLevel >= min
+	}
+}
+
+// FilterByTimeRange creates a filter for logs within a time range
+func (s Schema) FilterByTimeRange(start, end time.Time) FilterFunc {
+	s = s.withDefaults()
+	return func(log map[string]interface{}) bool {
+		t, ok := s.entryTime(log)
+		return ok && t.After(start) && t.Before(end)
+	}
+}
+
+// NewLogEntry builds a LogEntry from a raw record written with this schema.
+// Standard keys that are missing or cannot be parsed are left in Fields.
+func (s Schema) NewLogEntry(log map[string]interface{}) LogEntry {
+	s = s.withDefaults()
+	log = s.normalizePreset(log)
+	entry := LogEntry{Fields: make(map[string]any, len(log))}
+
+	for key, value := range log {
+		str, isString := value.(string)
+		switch {
+		case key == s.TimeKey:
+			if t, ok := s.parseTime(value); ok {
+				entry.Time = t
+				continue
+			}
+		case key == s.LevelKey && isString:
+			entry.Level = LogLevel(strings.ToLower(str))
+			continue
+		case key == s.MessageKey && isString:
+			entry.Message = str
+			continue
+		case key == s.CallerKey && isString:
+			entry.Caller = str
+			continue
+		case key == s.StacktraceKey && isString:
+			entry.Stacktrace = str
+			continue
+		case key == s.NameKey && isString:
+			entry.Logger = str
+			continue
+		}
+		entry.Fields[key] = value
+	}
+
+	return entry
+}
+
+// Map returns the entry as a raw record with this schema's keys and
+// formats, the form FilterFunc works on
+func (s Schema) Map(e LogEntry) map[string]interface{} {
+	s = s.withDefaults()
+	log := make(map[string]interface{}, len(e.Fields)+6)
+	for key, value := range e.Fields {
+		log[key] = value
+	}
+
+	if !e.Time.IsZero() {
+		switch s.TimeEncoding {
+		case EpochMillisTimeEncoding:
+			log[s.TimeKey] = float64(e.Time.UnixNano()) / float64(time.Millisecond)
+		case EpochNanosTimeEncoding:
+			log[s.TimeKey] = e.Time.UnixNano()
+		default:
+			log[s.TimeKey] = e.Time.Format(time.RFC3339Nano)
+		}
+	}
+
+	level := string(e.Level)
+	if s.LevelEncoding == UppercaseLevelEncoding {
+		level = strings.ToUpper(level)
+	}
+	setIfNotEmpty(log, s.LevelKey, level)
+	setIfNotEmpty(log, s.MessageKey, e.Message)
+	setIfNotEmpty(log, s.CallerKey, e.Caller)
+	setIfNotEmpty(log, s.StacktraceKey, e.Stacktrace)
+	setIfNotEmpty(log, s.NameKey, e.Logger)
+
+	return log
+}
+
+func setIfNotEmpty(log map[string]interface{}, key, value string) {
+	if value != "" {
+		log[key] = value
+	}
+}
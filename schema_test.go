@@ -0,0 +1,134 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCustomSchema(t *testing.T) {
+	for _, encoding := range []TimeEncoding{
+		ISO8601TimeEncoding, RFC3339NanoTimeEncoding, EpochMillisTimeEncoding, EpochNanosTimeEncoding,
+	} {
+		t.Run(string(encoding), func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			logger, err := NewLogger(Config{
+				LogPath:     tmpDir,
+				LogFileName: "test",
+				Schema: Schema{
+					TimeKey:       "ts",
+					LevelKey:      "severity",
+					MessageKey:    "msg",
+					TimeEncoding:  encoding,
+					LevelEncoding: UppercaseLevelEncoding,
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to create logger: %v", err)
+			}
+
+			start := time.Now().Add(-time.Second)
+			logger.Info("info message")
+			logger.Warn("warn message", zap.Int("count", 2))
+			logger.Close()
+			end := time.Now().Add(time.Second)
+
+			content, err := os.ReadFile(filepath.Join(tmpDir, "test.log"))
+			if err != nil {
+				t.Fatalf("failed to read log file: %v", err)
+			}
+			var first map[string]interface{}
+			if err := json.Unmarshal([]byte(strings.Split(string(content), "\n")[0]), &first); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if first["severity"] != "INFO" || first["msg"] != "info message" {
+				t.Errorf("custom keys not written: %v", first)
+			}
+			if _, ok := first["timestamp"]; ok {
+				t.Error("default time key should not be written")
+			}
+
+			schema := logger.Schema()
+			filter := And(
+				schema.FilterByMinLevel(WarnLevel),
+				schema.FilterByTimeRange(start, end),
+				schema.FilterByLevel("warn"),
+				MustParseQuery("count=2"),
+			)
+
+			reader, err := OpenLogFile(filepath.Join(tmpDir, "test.log"), filter)
+			if err != nil {
+				t.Fatalf("failed to open log file: %v", err)
+			}
+			defer reader.Close()
+			reader.Schema = schema
+
+			if !reader.Next() {
+				t.Fatalf("expected the warn entry, err: %v", reader.Err())
+			}
+			entry := reader.LogEntry()
+			if entry.Level != WarnLevel || entry.Message != "warn message" {
+				t.Errorf("unexpected entry: %+v", entry)
+			}
+			if entry.Time.Before(start) || entry.Time.After(end) {
+				t.Errorf("timestamp not parsed: %v", entry.Time)
+			}
+			if reader.Next() {
+				t.Error("expected a single matching entry")
+			}
+
+			query, err := schema.ParseQuery(`severity>=warn AND ts>"2000-01-01"`)
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+			if query(first) {
+				t.Error("query should not match the info entry")
+			}
+		})
+	}
+}
+
+func TestSchemaMapRoundTrip(t *testing.T) {
+	schema := Schema{TimeKey: "ts", TimeEncoding: EpochMillisTimeEncoding, LevelEncoding: UppercaseLevelEncoding}
+	entry := LogEntry{
+		Time:    time.UnixMilli(1764662397317),
+		Level:   ErrorLevel,
+		Message: "boom",
+		Fields:  map[string]any{},
+	}
+
+	log := schema.Map(entry)
+	if log["level"] != "ERROR" || log["ts"] != float64(1764662397317) {
+		t.Errorf("unexpected record: %v", log)
+	}
+
+	decoded := schema.NewLogEntry(log)
+	if !decoded.Time.Equal(entry.Time) || decoded.Level != ErrorLevel || decoded.Message != "boom" {
+		t.Errorf("round trip mismatch: %+v", decoded)
+	}
+}
+
+func TestSchemaValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema Schema
+	}{
+		{"unknown time encoding", Schema{TimeEncoding: "unix"}},
+		{"unknown level encoding", Schema{LevelEncoding: "title"}},
+		{"duplicate keys", Schema{MessageKey: "level"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLogger(Config{LogPath: t.TempDir(), Schema: tt.schema}); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+package jsonlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink is a log destination. It receives every entry encoded by the sink's
+// own encoder, together with the entry itself for sinks that need the level
+// or time (such as syslog).
+type Sink interface {
+	// WriteEntry writes one encoded entry. p is only valid during the call.
+	// It runs on the logging goroutine, one call at a time, so it must not
+	// block: a sink for a slow destination queues, as the network and HTTP
+	// sinks do.
+	WriteEntry(ent zapcore.Entry, p []byte) error
+	// Sync flushes buffered entries. It may run while WriteEntry does.
+	Sync() error
+	Close() error
+}
+
+// FieldSink is a Sink that lays out the entry's fields itself, such as
+// syslog structured data. WriteFields is called instead of WriteEntry with
+// the fields added by With followed by the entry's own.
+type FieldSink interface {
+	Sink
+	WriteFields(ent zapcore.Entry, fields []zapcore.Field, p []byte) error
+}
+
+// SinkFactory opens the sink for a URL whose scheme it was registered for
+type SinkFactory func(u *url.URL) (Sink, error)
+
+var (
+	sinkMu        sync.RWMutex
+	sinkFactories = map[string]SinkFactory{
+		"stdout": func(*url.URL) (Sink, error) { return WriterSink(os.Stdout), nil },
+		"stderr": func(*url.URL) (Sink, error) { return WriterSink(os.Stderr), nil },
+		"tcp":    newNetSinkFromURL,
+		"udp":    newNetSinkFromURL,
+		"unix":   newNetSinkFromURL,
+
+		"syslog":      newSyslogSinkFromURL,
+		"syslog+udp":  newSyslogSinkFromURL,
+		"syslog+tcp":  newSyslogSinkFromURL,
+		"syslog+unix": newSyslogSinkFromURL,
+
+		"http":  newHTTPSinkFromURL,
+		"https": newHTTPSinkFromURL,
+	}
+)
+
+// RegisterSink makes a sink available under a URL scheme for NewSink and
+// SinkConfig.URL. Registering a scheme twice is an error.
+func RegisterSink(scheme string, factory SinkFactory) error {
+	scheme = strings.ToLower(scheme)
+	if scheme == "" {
+		return errors.New("sink scheme is empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("sink factory for %q is nil", scheme)
+	}
+
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	if _, ok := sinkFactories[scheme]; ok {
+		return fmt.Errorf("sink scheme %q is already registered", scheme)
+	}
+	sinkFactories[scheme] = factory
+	return nil
+}
+
+// NewSink opens the sink for rawURL using the registered factories. Besides
+// scheme://... URLs it accepts a bare scheme such as "stderr".
+func NewSink(rawURL string) (Sink, error) {
+	var u *url.URL
+	if strings.Contains(rawURL, "://") {
+		var err error
+		if u, err = url.Parse(rawURL); err != nil {
+			return nil, fmt.Errorf("invalid sink URL %q: %w", rawURL, err)
+		}
+	} else {
+		u = &url.URL{Scheme: strings.TrimSuffix(rawURL, ":")}
+	}
+
+	sinkMu.RLock()
+	factory, ok := sinkFactories[strings.ToLower(u.Scheme)]
+	sinkMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no sink registered for scheme %q", u.Scheme)
+	}
+	return factory(u)
+}
+
+// registeredSinkSchemes lists the registered schemes, for error messages
+func registeredSinkSchemes() []string {
+	sinkMu.RLock()
+	defer sinkMu.RUnlock()
+	schemes := make([]string, 0, len(sinkFactories))
+	for scheme := range sinkFactories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// WriterSink adapts an io.Writer. Sync calls the writer's Sync method if it
+// has one; Close does nothing, the writer stays the caller's.
+func WriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+type writerSink struct {
+	w io.Writer
+}
+
+func (s *writerSink) WriteEntry(_ zapcore.Entry, p []byte) error {
+	_, err := s.w.Write(p)
+	return err
+}
+
+func (s *writerSink) Sync() error {
+	if syncer, ok := s.w.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// sinkDialTimeout bounds connecting and writing to network sinks, so a dead
+// endpoint cannot hold up their queue for long
+const sinkDialTimeout = 2 * time.Second
+
+// netSink writes entries to a TCP, UDP or Unix socket, one entry per write.
+// Entries are queued and written by a background flusher, so a slow or
+// unreachable endpoint never blocks logging: when the queue is full new
+// entries are dropped, and the entries of a failed write are given up.
+type netSink struct {
+	queue *asyncWriter
+}
+
+func newNetSinkFromURL(u *url.URL) (Sink, error) {
+	address := u.Host
+	if u.Scheme == "unix" {
+		address = u.Path
+	}
+	if address == "" {
+		return nil, fmt.Errorf("%s sink URL has no address", u.Scheme)
+	}
+	return newNetSink(u.Scheme, address), nil
+}
+
+// newNetSink starts the flusher of a sink for network and address
+func newNetSink(network, address string) *netSink {
+	queue := newAsyncWriter(&netConn{network: network, address: address}, AsyncConfig{
+		QueueSize:     defaultQueueSize,
+		FlushInterval: defaultFlushInterval,
+		Overflow:      OverflowDropNew,
+	})
+	queue.each = true
+	queue.start()
+	return &netSink{queue: queue}
+}
+
+func (s *netSink) WriteEntry(_ zapcore.Entry, p []byte) error {
+	return s.write(p)
+}
+
+func (s *netSink) write(p []byte) error {
+	_, err := s.queue.Write(p)
+	return err
+}
+
+// Sync writes out the queue
+func (s *netSink) Sync() error {
+	return s.queue.Sync()
+}
+
+// Close writes out the queue and closes the connection
+func (s *netSink) Close() error {
+	err := s.queue.close()
+	return errors.Join(err, s.queue.out.(*netConn).Close())
+}
+
+// stats returns the queue's counters: entries written to the connection,
+// given up and still queued
+func (s *netSink) stats() (written, dropped uint64, pending int, lastError error) {
+	w := s.queue
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written, w.dropped + w.failed, w.count, w.err
+}
+
+// netConn is the connection of a netSink, used only by its flusher. It
+// connects on first use and reconnects after a failed write.
+type netConn struct {
+	network string
+	address string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+func (c *netConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, errors.New("network sink is closed")
+	}
+	if c.conn == nil {
+		conn, err := net.DialTimeout(c.network, c.address, sinkDialTimeout)
+		if err != nil {
+			return 0, err
+		}
+		c.conn = conn
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(sinkDialTimeout))
+	n, err := c.conn.Write(p)
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	return n, err
+}
+
+func (c *netConn) Sync() error {
+	return nil
+}
+
+func (c *netConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// SinkConfig configures one extra destination. Exactly one of URL, Writer,
+// Sink and HTTP must be set.
+type SinkConfig struct {
+	// Name identifies the sink in SinkStats (empty = URL, "writer" or "sink")
+	Name string
+
+	// URL opens a registered sink: "stdout", "stderr", "tcp://host:port",
+	// "udp://host:port", "unix:///path", "syslog://host:514",
+	// "https://host/path", or a scheme added with RegisterSink
+	URL string
+
+	// Writer writes entries to an io.Writer, on the logging goroutine. It
+	// must not block: a pipe nobody reads stalls every logging goroutine.
+	Writer io.Writer
+
+	// Sink writes entries to a custom Sink, which must not block either
+	Sink Sink
+
+	// HTTP ships entries in batches to an HTTP endpoint. A relative
+	// SpoolDir is taken under Config.LogPath.
+	HTTP *HTTPSinkConfig
+
+	// OutputConfig sets the sink's level and encoding (default: JSON)
+	OutputConfig
+}
+
+// SinkStats reports the activity of one sink
+type SinkStats struct {
+	Name      string
+	Written   uint64 // entries written, or sent for a network sink
+	Failed    uint64 // entries whose write failed
+	Dropped   uint64 // entries skipped while the sink was failing or given up by it
+	Pending   int    // entries a queueing sink has yet to deliver
+	LastError error
+}
+
+// sinkRetryDelay is how long a sink is skipped after a failed write
+const sinkRetryDelay = 5 * time.Second
+
+// isolatedSink keeps one sink's errors away from the other outputs. After a
+// failed write the sink is skipped for sinkRetryDelay, so a dead endpoint
+// costs at most one timeout per delay. It does not guard against a sink
+// that blocks: writes hold the lock, and Sink implementations must not
+// block.
+type isolatedSink struct {
+	name string
+	sink Sink
+
+	mu         sync.Mutex
+	retryAfter time.Time
+	closed     bool
+	stats      SinkStats
+}
+
+func (s *isolatedSink) write(ent zapcore.Entry, fields []zapcore.Field, p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.closed || now.Before(s.retryAfter) {
+		s.stats.Dropped++
+		return
+	}
+	var err error
+	if fieldSink, ok := s.sink.(FieldSink); ok {
+		err = fieldSink.WriteFields(ent, fields, p)
+	} else {
+		err = s.sink.WriteEntry(ent, p)
+	}
+	if err != nil {
+		s.stats.Failed++
+		s.stats.LastError = err
+		s.retryAfter = now.Add(sinkRetryDelay)
+		return
+	}
+	s.stats.Written++
+}
+
+// sync runs without the lock: a sink may take a while to flush, and the
+// other goroutines' writes must not wait for it
+func (s *isolatedSink) sync() {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return
+	}
+
+	if err := s.sink.Sync(); err != nil {
+		s.mu.Lock()
+		s.stats.LastError = err
+		s.mu.Unlock()
+	}
+}
+
+func (s *isolatedSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if err := s.sink.Close(); err != nil {
+		s.stats.LastError = err
+		return fmt.Errorf("sink %s: %w", s.name, err)
+	}
+	return nil
+}
+
+func (s *isolatedSink) snapshot() SinkStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Name = s.name
+	switch sink := s.sink.(type) {
+	case *HTTPSink:
+		delivery := sink.Stats()
+		stats.Dropped += delivery.Dropped
+		stats.Pending = delivery.Pending
+		if delivery.LastError != nil {
+			stats.LastError = delivery.LastError
+		}
+	case *netSink:
+		written, dropped, pending, err := sink.stats()
+		stats.Written = written
+		stats.Dropped += dropped
+		stats.Pending = pending
+		if err != nil {
+			stats.LastError = err
+		}
+	}
+	return stats
+}
+
+// openSink builds the isolated sink for a SinkConfig
+func openSink(config SinkConfig, logPath string) (*isolatedSink, error) {
+	set := 0
+	for _, ok := range []bool{config.URL != "", config.Writer != nil, config.Sink != nil, config.HTTP != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of URL, Writer, Sink and HTTP must be set")
+	}
+
+	name := config.Name
+	var sink Sink
+	switch {
+	case config.URL != "":
+		var err error
+		if sink, err = NewSink(config.URL); err != nil {
+			return nil, fmt.Errorf("%w (registered: %s)", err, strings.Join(registeredSinkSchemes(), ", "))
+		}
+		if name == "" {
+			name = config.URL
+		}
+	case config.HTTP != nil:
+		httpConfig := *config.HTTP
+		if httpConfig.SpoolDir != "" && !filepath.IsAbs(httpConfig.SpoolDir) {
+			httpConfig.SpoolDir = filepath.Join(logPath, httpConfig.SpoolDir)
+		}
+		var err error
+		if sink, err = NewHTTPSink(httpConfig); err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = httpConfig.URL
+		}
+	case config.Writer != nil:
+		sink = WriterSink(config.Writer)
+		if name == "" {
+			name = "writer"
+		}
+	default:
+		sink = config.Sink
+		if name == "" {
+			name = "sink"
+		}
+	}
+
+	return &isolatedSink{name: name, sink: sink}, nil
+}
+
+// sinkCore encodes entries for one sink. Write errors stay with the sink and
+// are never returned, so the other outputs always see the entry.
+type sinkCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	sink    *isolatedSink
+	fields  []zapcore.Field // context fields, kept only for a FieldSink
+}
+
+// newSinkCore builds the core for one configured sink
+func newSinkCore(sink *isolatedSink, output OutputConfig, schema Schema, level zap.AtomicLevel) (zapcore.Core, error) {
+	encoder, enabler, err := newOutputEncoder(output, JSONEncoding, schema, level)
+	if err != nil {
+		return nil, err
+	}
+	core := &sinkCore{LevelEnabler: enabler, encoder: encoder, sink: sink}
+	return schema.wrapCore(core), nil
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	clone := &sinkCore{LevelEnabler: c.LevelEnabler, encoder: encoder, sink: c.sink, fields: c.fields}
+	if _, ok := c.sink.sink.(FieldSink); ok {
+		clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	}
+	return clone
+}
+
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	if len(c.fields) > 0 {
+		fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	}
+	c.sink.write(ent, fields, buf.Bytes())
+	buf.Free()
+
+	if ent.Level > zapcore.ErrorLevel {
+		c.sink.sync()
+	}
+	return nil
+}
+
+func (c *sinkCore) Sync() error {
+	c.sink.sync()
+	return nil
+}
+
+// SinkStats returns the counters of the sinks in Config.Sinks, in order
+func (l *Logger) SinkStats() []SinkStats {
+	stats := make([]SinkStats, len(l.sinks))
+	for i, sink := range l.sinks {
+		stats[i] = sink.snapshot()
+	}
+	return stats
+}
@@ -0,0 +1,443 @@
+package jsonlog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// failingSink fails every write
+type failingSink struct {
+	mu     sync.Mutex
+	writes int
+}
+
+func (s *failingSink) WriteEntry(zapcore.Entry, []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes++
+	return errors.New("sink is down")
+}
+
+func (s *failingSink) Sync() error  { return nil }
+func (s *failingSink) Close() error { return nil }
+
+func TestWriterSinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	warnings := &syncBuffer{}
+	console := &syncBuffer{}
+
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Sinks: []SinkConfig{
+			{Writer: warnings, OutputConfig: OutputConfig{Level: WarnLevel}},
+			{Writer: console, OutputConfig: OutputConfig{Encoding: ConsoleEncoding}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.With(zap.String("request_id", "r1")).Info("info message")
+	logger.Warn("warn message")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	if records := readRecords(t, filepath.Join(tmpDir, "test.log")); len(records) != 2 {
+		t.Errorf("expected 2 records in the file, got %d", len(records))
+	}
+	if got := warnings.String(); strings.Contains(got, "info message") || !strings.Contains(got, `"message":"warn message"`) {
+		t.Errorf("unexpected warn sink output: %q", got)
+	}
+	lines := strings.Split(strings.TrimSpace(console.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "\tinfo\t") || !strings.Contains(lines[0], `"request_id": "r1"`) {
+		t.Errorf("unexpected console sink output: %q", lines)
+	}
+
+	stats := logger.SinkStats()
+	if len(stats) != 2 || stats[0].Name != "writer" || stats[0].Written != 1 || stats[1].Written != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestFailingSinkIsolated(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A port that nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	deadAddress := listener.Addr().String()
+	listener.Close()
+
+	failing := &failingSink{}
+	logger, err := NewLogger(Config{
+		LogPath:     tmpDir,
+		LogFileName: "test",
+		Sinks: []SinkConfig{
+			{Name: "failing", Sink: failing},
+			{URL: "tcp://" + deadAddress},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		logger.Error("still written to the file")
+	}
+	// The network sink gives up its queue when it cannot connect
+	err = logger.Close()
+	if err == nil || !strings.Contains(err.Error(), "sink tcp://"+deadAddress) {
+		t.Errorf("Close should report the undelivered network sink, got %v", err)
+	}
+
+	if records := readRecords(t, filepath.Join(tmpDir, "test.log")); len(records) != 5 {
+		t.Errorf("expected 5 records in the file, got %d", len(records))
+	}
+	if failing.writes != 1 {
+		t.Errorf("failing sink should be skipped after a failure, got %d writes", failing.writes)
+	}
+	stats := logger.SinkStats()
+	if stats[0].Failed != 1 || stats[0].Dropped != 4 || stats[0].LastError == nil {
+		t.Errorf("unexpected stats: %+v", stats[0])
+	}
+	if stats[1].Written != 0 || stats[1].Dropped != 5 || stats[1].Pending != 0 || stats[1].LastError == nil {
+		t.Errorf("unexpected network sink stats: %+v", stats[1])
+	}
+}
+
+func TestNetSinkDoesNotBlock(t *testing.T) {
+	// An endpoint that accepts but never reads, so that writes block once
+	// the socket buffers are full
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	logger, err := NewLogger(Config{
+		DisableFile: true,
+		Sinks:       []SinkConfig{{URL: "tcp://" + listener.Addr().String()}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	payload := strings.Repeat("x", 64*1024)
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		logger.Info("stalled endpoint", zap.String("payload", payload))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("logging waited %s for a stalled network sink", elapsed)
+	}
+
+	// Unblock the flusher so that Close does not wait on write timeouts
+	select {
+	case conn := <-accepted:
+		go io.Copy(io.Discard, conn)
+		defer conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("the sink did not connect")
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("failed to close logger: %v", err)
+	}
+	if stats := logger.SinkStats()[0]; stats.Written+stats.Dropped != 200 {
+		t.Errorf("every entry should be sent or dropped: %+v", stats)
+	}
+}
+
+// blockingSink holds Sync until released
+type blockingSink struct {
+	syncing chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSink) WriteEntry(zapcore.Entry, []byte) error { return nil }
+func (s *blockingSink) Close() error                           { return nil }
+
+func (s *blockingSink) Sync() error {
+	close(s.syncing)
+	<-s.release
+	return nil
+}
+
+func TestSinkSyncDoesNotBlockWrites(t *testing.T) {
+	blocking := &blockingSink{syncing: make(chan struct{}), release: make(chan struct{})}
+	sink := &isolatedSink{name: "blocking", sink: blocking}
+
+	synced := make(chan struct{})
+	go func() {
+		sink.sync()
+		close(synced)
+	}()
+	<-blocking.syncing
+
+	written := make(chan struct{})
+	go func() {
+		sink.write(zapcore.Entry{}, nil, []byte("{}\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write waited for Sync")
+	}
+
+	close(blocking.release)
+	<-synced
+	if stats := sink.snapshot(); stats.Written != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestNetSinks(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer tcpListener.Close()
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer udpConn.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := tcpListener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	logger, err := NewLogger(Config{
+		LogPath: t.TempDir(),
+		Sinks: []SinkConfig{
+			{URL: "tcp://" + tcpListener.Addr().String()},
+			{URL: "udp://" + udpConn.LocalAddr().String()},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("over the network")
+
+	select {
+	case line := <-received:
+		if !strings.Contains(line, `"message":"over the network"`) {
+			t.Errorf("unexpected tcp line: %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tcp sink received nothing")
+	}
+
+	buf := make([]byte, 4096)
+	udpConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := udpConn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("udp sink received nothing: %v", err)
+	}
+	if !strings.Contains(string(buf[:n]), `"message":"over the network"`) {
+		t.Errorf("unexpected udp datagram: %q", buf[:n])
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer udpConn.Close()
+
+	logger, err := NewLogger(Config{
+		LogPath: t.TempDir(),
+		Sinks: []SinkConfig{
+			{URL: "syslog://" + udpConn.LocalAddr().String() + "?facility=local0&app=myapp&hostname=web1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.With(zap.String("mount", "/var")).Warn("disk almost full", zap.Int("free_mb", 12))
+
+	buf := make([]byte, 4096)
+	udpConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := udpConn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("syslog sink sent nothing: %v", err)
+	}
+	message := string(buf[:n])
+
+	// local0 (16) * 8 + warning (4)
+	if !strings.HasPrefix(message, "<132>1 ") {
+		t.Errorf("unexpected priority: %q", message)
+	}
+	fields := strings.SplitN(message, " ", 7)
+	if len(fields) != 7 || fields[2] != "web1" || fields[3] != "myapp" || fields[5] != "-" {
+		t.Errorf("unexpected header: %q", message)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, fields[1]); err != nil {
+		t.Errorf("invalid timestamp %q: %v", fields[1], err)
+	}
+	if want := `[fields@32473 free_mb="12" mount="/var"] disk almost full`; fields[6] != want {
+		t.Errorf("unexpected structured data and MSG: %q", fields[6])
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	bodies := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	logger, err := NewLogger(Config{
+		LogPath: t.TempDir(),
+		Sinks:   []SinkConfig{{URL: server.URL + "/logs"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Info("posted")
+	logger.Close()
+
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, `"message":"posted"`) {
+			t.Errorf("unexpected body: %q", body)
+		}
+	default:
+		t.Fatal("http sink posted nothing")
+	}
+}
+
+// unregisterSink removes a scheme registered by a test
+func unregisterSink(scheme string) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	delete(sinkFactories, scheme)
+}
+
+func TestRegisterSink(t *testing.T) {
+	buffer := &syncBuffer{}
+	factory := func(u *url.URL) (Sink, error) { return WriterSink(buffer), nil }
+
+	if err := RegisterSink("memory-test", factory); err != nil {
+		t.Fatalf("failed to register sink: %v", err)
+	}
+	// The registry is global; leave it as found for -count and other tests
+	t.Cleanup(func() { unregisterSink("memory-test") })
+	if err := RegisterSink("memory-test", factory); err == nil {
+		t.Error("registering a scheme twice should fail")
+	}
+	if err := RegisterSink("stderr", factory); err == nil {
+		t.Error("overriding a built-in scheme should fail")
+	}
+
+	logger, err := NewLogger(Config{LogPath: t.TempDir(), Sinks: []SinkConfig{{URL: "memory-test://anything"}}})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Info("custom sink")
+	logger.Close()
+
+	if !strings.Contains(buffer.String(), "custom sink") {
+		t.Errorf("custom sink received %q", buffer.String())
+	}
+}
+
+func TestSinkValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		sink SinkConfig
+	}{
+		{"nothing set", SinkConfig{}},
+		{"two destinations", SinkConfig{URL: "stderr", Writer: io.Discard}},
+		{"unknown scheme", SinkConfig{URL: "carrier-pigeon://coop"}},
+		{"missing address", SinkConfig{URL: "tcp://"}},
+		{"unknown facility", SinkConfig{URL: "syslog://localhost:514?facility=nope"}},
+		{"unknown syslog format", SinkConfig{URL: "syslog://localhost:514?format=rfc1"}},
+		{"invalid encoding", SinkConfig{URL: "stderr", OutputConfig: OutputConfig{Encoding: "xml"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLogger(Config{LogPath: t.TempDir(), Sinks: []SinkConfig{tt.sink}}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestInvalidConfigClosesSinks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	_, server := newCollector(t)
+	opened := []SinkConfig{
+		{URL: "tcp://" + listener.Addr().String()},
+		{HTTP: &HTTPSinkConfig{URL: server.URL}},
+	}
+
+	configs := []Config{
+		// A setting validated after the sinks are opened
+		{LogPath: t.TempDir(), Sinks: opened, Sampling: &SamplingConfig{First: 0}},
+		// A later sink that cannot be opened
+		{LogPath: t.TempDir(), Sinks: append(opened[:2:2], SinkConfig{URL: "carrier-pigeon://coop"})},
+		// A later sink with an invalid encoding
+		{LogPath: t.TempDir(), Sinks: append(opened[:2:2], SinkConfig{URL: "stderr", OutputConfig: OutputConfig{Encoding: "xml"}})},
+	}
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		for _, config := range configs {
+			if _, err := NewLogger(config); err == nil {
+				t.Fatal("expected an error")
+			}
+		}
+	}
+
+	// The sinks' goroutines stop once they are closed
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before+2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("sinks of rejected configs left %d goroutines running", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,181 @@
+package jsonlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogOptions configures the handler returned by Logger.SlogHandler
+type SlogOptions struct {
+	// AddSource writes the position of the slog call under the schema's
+	// caller key, as Logger.Info does
+	AddSource bool
+}
+
+// slogHandler is a slog.Handler that writes through a Logger's outputs
+type slogHandler struct {
+	logger  *Logger
+	core    zapcore.Core
+	options SlogOptions
+	groups  []string // opened by WithGroup, applied with the first attribute
+}
+
+// SlogHandler returns a slog.Handler that writes records to the logger's
+// outputs with the same schema as Logger.Info. Groups become nested
+// objects; levels map onto LogLevel (below info = debug, below warn =
+// info, below error = warn, error and above = error), so slog never exits
+// or panics. ContextExtractors run on the context passed to slog.
+//
+//	slog.SetDefault(slog.New(logger.SlogHandler(jsonlog.SlogOptions{AddSource: true})))
+func (l *Logger) SlogHandler(options SlogOptions) slog.Handler {
+	return &slogHandler{logger: l, core: l.zapLogger.Core(), options: options}
+}
+
+// slogLevel maps a slog level onto the zap level of a LogLevel
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	ent := zapcore.Entry{
+		Level:      slogLevel(record.Level),
+		Time:       record.Time,
+		Message:    record.Message,
+		LoggerName: h.logger.zapLogger.Name(),
+	}
+	if ent.Time.IsZero() {
+		ent.Time = time.Now()
+	}
+	if h.options.AddSource && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ent.Caller.Function = frame.Function
+	}
+
+	checked := h.core.Check(ent, nil)
+	if checked == nil {
+		return nil
+	}
+
+	fields := h.logger.contextFields(ctx, nil)
+	attrs := make([]zap.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		if field, ok := slogField(attr); ok {
+			attrs = append(attrs, field)
+		}
+		return true
+	})
+	if len(attrs) > 0 {
+		fields = append(fields, h.openGroups()...)
+		fields = append(fields, attrs...)
+	}
+
+	checked.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, attr := range attrs {
+		if field, ok := slogField(attr); ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return h
+	}
+
+	child := *h
+	child.core = h.core.With(append(h.openGroups(), fields...))
+	child.groups = nil
+	return &child
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &child
+}
+
+// openGroups returns the namespaces for the pending groups. Groups are
+// opened lazily so that a group without attributes is not written.
+func (h *slogHandler) openGroups() []zap.Field {
+	fields := make([]zap.Field, len(h.groups))
+	for i, group := range h.groups {
+		fields[i] = zap.Namespace(group)
+	}
+	return fields
+}
+
+// slogField converts an attribute; empty attributes and groups are dropped
+func slogField(attr slog.Attr) (zap.Field, bool) {
+	value := attr.Value.Resolve()
+	key := attr.Key
+
+	switch value.Kind() {
+	case slog.KindString:
+		return zap.String(key, value.String()), true
+	case slog.KindInt64:
+		return zap.Int64(key, value.Int64()), true
+	case slog.KindUint64:
+		return zap.Uint64(key, value.Uint64()), true
+	case slog.KindFloat64:
+		return zap.Float64(key, value.Float64()), true
+	case slog.KindBool:
+		return zap.Bool(key, value.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(key, value.Duration()), true
+	case slog.KindTime:
+		return zap.Time(key, value.Time()), true
+	case slog.KindGroup:
+		group := slogGroup(value.Group())
+		if len(group) == 0 {
+			return zap.Field{}, false
+		}
+		if key == "" {
+			return zap.Inline(group), true
+		}
+		return zap.Object(key, group), true
+	default:
+		if key == "" && value.Any() == nil {
+			return zap.Field{}, false
+		}
+		if err, ok := value.Any().(error); ok {
+			return zap.NamedError(key, err), true
+		}
+		return zap.Any(key, value.Any()), true
+	}
+}
+
+// slogGroup writes the attributes of a group as a nested object
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		if field, ok := slogField(attr); ok {
+			field.AddTo(enc)
+		}
+	}
+	return nil
+}
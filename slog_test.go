@@ -0,0 +1,160 @@
+package jsonlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recordKeys lists the keys of a record, sorted
+func recordKeys(record map[string]interface{}) []string {
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestSlogHandlerSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("hello", zap.String("user", "alice"))
+	slog.New(logger.SlogHandler(SlogOptions{AddSource: true})).Info("hello", "user", "alice")
+	logger.Close()
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	direct, viaSlog := records[0], records[1]
+	if got, want := strings.Join(recordKeys(viaSlog), ","), strings.Join(recordKeys(direct), ","); got != want {
+		t.Errorf("slog record keys %s, want %s", got, want)
+	}
+	for _, key := range []string{"level", "message", "user"} {
+		if viaSlog[key] != direct[key] {
+			t.Errorf("%s = %v, want %v", key, viaSlog[key], direct[key])
+		}
+	}
+	for _, record := range records {
+		if caller, _ := record["caller"].(string); !strings.Contains(caller, "slog_test.go:") {
+			t.Errorf("caller should point at the logging call, got %q", caller)
+		}
+	}
+	if _, err := parseTimestamp(viaSlog["timestamp"].(string)); err != nil {
+		t.Errorf("timestamp not in the logger's format: %v", err)
+	}
+}
+
+func TestSlogHandlerAttrsAndGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	base := slog.New(logger.Named("api").SlogHandler(SlogOptions{}))
+	requestLogger := base.With("request_id", "r1").WithGroup("http").With("method", "GET")
+	requestLogger.Info("served",
+		slog.Int("status", 200),
+		slog.Duration("took", 1500*time.Millisecond),
+		slog.Group("client", slog.String("ip", "10.0.0.1")),
+		slog.Any("err", errors.New("none")),
+		slog.Group("empty"),
+	)
+	base.WithGroup("unused").Info("no attributes")
+	logger.Close()
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	record := records[0]
+	if record["request_id"] != "r1" || record["logger"] != "api" {
+		t.Errorf("unexpected top-level fields: %v", record)
+	}
+	if _, ok := record["caller"]; ok {
+		t.Errorf("caller written without AddSource: %v", record)
+	}
+	http, ok := record["http"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("group not written as an object: %v", record)
+	}
+	client, _ := http["client"].(map[string]interface{})
+	if http["method"] != "GET" || http["status"] != float64(200) || http["err"] != "none" || client["ip"] != "10.0.0.1" {
+		t.Errorf("unexpected group contents: %v", http)
+	}
+	if _, ok := http["empty"]; ok {
+		t.Errorf("empty group should be omitted: %v", http)
+	}
+
+	if _, ok := records[1]["unused"]; ok {
+		t.Errorf("group without attributes should be omitted: %v", records[1])
+	}
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test", Level: InfoLevel})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	handler := logger.SlogHandler(SlogOptions{})
+	ctx := context.Background()
+
+	if handler.Enabled(ctx, slog.LevelDebug) || !handler.Enabled(ctx, slog.LevelInfo) {
+		t.Error("handler should follow the logger's level")
+	}
+	logger.SetLevel(ErrorLevel)
+	if handler.Enabled(ctx, slog.LevelWarn) {
+		t.Error("handler should follow SetLevel")
+	}
+	logger.SetLevel(DebugLevel)
+
+	slogger := slog.New(handler)
+	for _, level := range []slog.Level{slog.LevelDebug - 4, slog.LevelInfo + 2, slog.LevelWarn, slog.LevelError + 4} {
+		slogger.Log(ctx, level, level.String())
+	}
+	logger.Close()
+
+	var levels []string
+	for _, record := range readRecords(t, filepath.Join(tmpDir, "test.log")) {
+		levels = append(levels, record["level"].(string))
+	}
+	if got := strings.Join(levels, ","); got != "debug,info,warn,error" {
+		t.Errorf("levels = %s", got)
+	}
+}
+
+func TestSlogHandlerContextExtractors(t *testing.T) {
+	type traceKey struct{}
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{
+		LogPath:           tmpDir,
+		LogFileName:       "test",
+		ContextExtractors: []ContextExtractor{ContextValueExtractor(traceKey{}, "trace_id")},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "t-1")
+	slog.New(logger.SlogHandler(SlogOptions{})).InfoContext(ctx, "traced")
+	logger.Close()
+
+	if records := readRecords(t, filepath.Join(tmpDir, "test.log")); records[0]["trace_id"] != "t-1" {
+		t.Errorf("trace_id not extracted: %v", records[0])
+	}
+}
@@ -0,0 +1,224 @@
+package jsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stdLogCallerSkip is the number of frames between LineWriter.log and the
+// caller of log.Printf and friends
+const stdLogCallerSkip = 5
+
+// LineWriter turns each line written to it into an entry at a fixed level,
+// for libraries that log to an io.Writer, the standard log package or a
+// Printf function. It never exits or panics, even at FatalLevel or
+// PanicLevel. A partial line is kept until its newline arrives or Sync is
+// called.
+type LineWriter struct {
+	// ParseJSON merges the fields of lines that are JSON objects into the
+	// entry. Their message, level and time keys ("msg"/"message",
+	// "level"/"severity", "time"/"ts"/"timestamp" and the schema's own keys)
+	// set the entry's instead.
+	ParseJSON bool
+
+	logger     *Logger
+	level      zapcore.Level
+	callerSkip int // see log
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+// Writer returns a LineWriter that logs at level; an unknown level logs at
+// info, as LogWithLevel does
+func (l *Logger) Writer(level LogLevel) *LineWriter {
+	zapLevel, err := toZapLevel(level)
+	if err != nil {
+		zapLevel = zapcore.InfoLevel
+	}
+	return &LineWriter{logger: l, level: zapLevel}
+}
+
+// RedirectStdLog sends the standard log package's output to the logger at
+// level, with the caller of log.Printf as the entry's caller. The returned
+// function restores the previous output, flags and prefix.
+func (l *Logger) RedirectStdLog(level LogLevel) func() {
+	return l.Writer(level).RedirectStdLog()
+}
+
+// RedirectStdLog sends the standard log package's output to w; see
+// Logger.RedirectStdLog
+func (w *LineWriter) RedirectStdLog() func() {
+	redirected := &LineWriter{ParseJSON: w.ParseJSON, logger: w.logger, level: w.level, callerSkip: stdLogCallerSkip}
+
+	flags, prefix, output := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(redirected)
+
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(output)
+	}
+}
+
+// Write logs every complete line in p
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.write(p, w.callerSkip)
+	return len(p), nil
+}
+
+// Printf logs a formatted line, with the caller of Printf as the entry's
+// caller. It fits libraries that take a Printf-style logger.
+func (w *LineWriter) Printf(format string, args ...interface{}) {
+	w.write([]byte(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")+"\n"), 3)
+}
+
+// Sync logs a pending partial line
+func (w *LineWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.log(w.partial, 0)
+		w.partial = w.partial[:0]
+	}
+	return nil
+}
+
+func (w *LineWriter) write(p []byte, skip int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.partial, p...)
+	for {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		w.log(data[:end], skip)
+		data = data[end+1:]
+	}
+	w.partial = append(w.partial[:0], data...)
+}
+
+// log writes one line; runtime.Caller(skip) in log is the caller (0 = none)
+func (w *LineWriter) log(line []byte, skip int) {
+	line = bytes.TrimRight(line, "\r")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+
+	ent := zapcore.Entry{
+		Level:      w.level,
+		Time:       time.Now(),
+		Message:    string(line),
+		LoggerName: w.logger.zapLogger.Name(),
+	}
+	var fields []zap.Field
+	if w.ParseJSON {
+		fields = w.parseJSON(&ent, line)
+	}
+	if skip > 0 {
+		if pc, file, lineNumber, ok := runtime.Caller(skip); ok {
+			ent.Caller = zapcore.NewEntryCaller(pc, file, lineNumber, true)
+		}
+	}
+
+	if checked := w.logger.zapLogger.Core().Check(ent, nil); checked != nil {
+		checked.Write(fields...)
+	}
+}
+
+// parseJSON fills ent from a line holding a JSON object and returns its
+// other keys as fields. Other lines are left alone.
+func (w *LineWriter) parseJSON(ent *zapcore.Entry, line []byte) []zap.Field {
+	if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("{")) {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if decoder.Decode(&object) != nil || decoder.More() {
+		return nil
+	}
+
+	schema := w.logger.schema.withDefaults()
+	reserved := make(map[string]bool)
+	for _, key := range schema.keys() {
+		reserved[key] = true
+	}
+
+	fields := make([]zap.Field, 0, len(object))
+	for key, value := range object {
+		switch {
+		case key == "msg" || key == "message" || key == schema.MessageKey:
+			ent.Message = fmt.Sprint(value)
+		case key == "level" || key == "severity" || key == schema.LevelKey:
+			if level, ok := parseLevelName(value); ok {
+				ent.Level = level
+			}
+		case key == "time" || key == "ts" || key == "timestamp" || key == schema.TimeKey:
+			if t, ok := parseTimeValue(value); ok {
+				ent.Time = t
+			}
+		case reserved[key]:
+			// Would clash with the entry's own caller, stack or name
+		default:
+			if n, ok := value.(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					value = i
+				} else if f, err := n.Float64(); err == nil {
+					value = f
+				}
+			}
+			fields = append(fields, zap.Any(key, value))
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+// parseLevelName reads level names such as "warn", "WARNING" or "error"
+func parseLevelName(value interface{}) (zapcore.Level, bool) {
+	name, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	name = strings.ToLower(name)
+	if name == "warning" {
+		name = "warn"
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, false
+	}
+	return level, true
+}
+
+// parseTimeValue reads RFC 3339 strings and Unix seconds
+func parseTimeValue(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := parseTimestamp(v)
+		return t, err == nil
+	case json.Number:
+		seconds, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(0, int64(seconds*float64(time.Second))), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,117 @@
+package jsonlog
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	log.SetPrefix("lib: ")
+	previous := log.Writer()
+	restore := logger.RedirectStdLog(WarnLevel)
+	log.Printf("retrying in %ds", 5)
+	log.Print("first line\nsecond line")
+	restore()
+	logger.Close()
+
+	if log.Prefix() != "lib: " || log.Writer() != previous {
+		t.Error("restore should bring back the previous prefix and output")
+	}
+	log.SetPrefix("")
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d: %v", len(records), records)
+	}
+	for i, want := range []string{"retrying in 5s", "first line", "second line"} {
+		if records[i]["message"] != want || records[i]["level"] != "warn" {
+			t.Errorf("record %d = %v, want warn %q", i, records[i], want)
+		}
+	}
+	if caller, _ := records[0]["caller"].(string); !strings.Contains(caller, "stdlog_test.go:") {
+		t.Errorf("caller should be the log.Printf call, got %q", caller)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	writer := logger.Named("lib").Writer(InfoLevel)
+	fmt.Fprint(writer, "partial ")
+	fmt.Fprint(writer, "line\r\n\n")
+	fmt.Fprint(writer, "no newline")
+	writer.Sync()
+	writer.Printf("printf %s", "style")
+
+	// JSON lines keep plain text as it is until ParseJSON is set
+	fmt.Fprintln(writer, `{"msg":"as text"}`)
+	writer.ParseJSON = true
+	fmt.Fprintln(writer, `{"level":"warning","msg":"disk slow","time":"2024-05-01T10:00:00Z","device":"sda","latency_ms":120,"tags":["io"],"caller":"elsewhere.go:1"}`)
+	fmt.Fprintln(writer, `{"unterminated":`)
+	logger.Close()
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	var messages []string
+	for _, record := range records {
+		messages = append(messages, record["message"].(string))
+	}
+	want := []string{"partial line", "no newline", "printf style", `{"msg":"as text"}`, "disk slow", `{"unterminated":`}
+	if strings.Join(messages, "|") != strings.Join(want, "|") {
+		t.Fatalf("messages = %q, want %q", messages, want)
+	}
+
+	if records[0]["logger"] != "lib" || records[0]["level"] != "info" {
+		t.Errorf("unexpected record: %v", records[0])
+	}
+	if _, ok := records[0]["caller"]; ok {
+		t.Errorf("plain writes have no caller: %v", records[0])
+	}
+	if caller, _ := records[2]["caller"].(string); !strings.Contains(caller, "stdlog_test.go:") {
+		t.Errorf("Printf caller should be the test, got %q", caller)
+	}
+
+	parsed := records[4]
+	if parsed["level"] != "warn" || parsed["device"] != "sda" || parsed["latency_ms"] != float64(120) {
+		t.Errorf("unexpected parsed record: %v", parsed)
+	}
+	if !strings.HasPrefix(parsed["timestamp"].(string), "2024-05-01T10:00:00") {
+		t.Errorf("time not taken from the line: %v", parsed["timestamp"])
+	}
+	if tags, ok := parsed["tags"].([]interface{}); !ok || len(tags) != 1 {
+		t.Errorf("unexpected tags: %v", parsed["tags"])
+	}
+	if _, ok := parsed["caller"]; ok {
+		t.Errorf("reserved keys from the line should be dropped: %v", parsed)
+	}
+}
+
+func TestLineWriterLevels(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := NewLogger(Config{LogPath: tmpDir, LogFileName: "test", Level: WarnLevel})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	fmt.Fprintln(logger.Writer(InfoLevel), "below the minimum")
+	fmt.Fprintln(logger.Writer("verbose"), "unknown level logs at info")
+	fmt.Fprintln(logger.Writer(FatalLevel), "written without exiting")
+	logger.Close()
+
+	records := readRecords(t, filepath.Join(tmpDir, "test.log"))
+	if len(records) != 1 || records[0]["level"] != "fatal" {
+		t.Errorf("unexpected records: %v", records)
+	}
+}
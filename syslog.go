@@ -0,0 +1,580 @@
+package jsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogSDID is the SD-ID of the structured data element that carries an
+// entry's fields in RFC 5424 messages
+const SyslogSDID = "fields@32473"
+
+// syslogFacilities maps facility names to their codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverity maps zap levels to syslog severities
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7 // debug
+	case zapcore.InfoLevel:
+		return 6 // informational
+	case zapcore.WarnLevel:
+		return 4 // warning
+	case zapcore.ErrorLevel:
+		return 3 // error
+	case zapcore.DPanicLevel:
+		return 2 // critical
+	case zapcore.PanicLevel:
+		return 1 // alert
+	default:
+		return 0 // emergency
+	}
+}
+
+// syslogLevel maps a syslog severity back to a zap level; notice becomes
+// info
+func syslogLevel(severity int) zapcore.Level {
+	switch severity {
+	case 0:
+		return zapcore.FatalLevel
+	case 1:
+		return zapcore.PanicLevel
+	case 2:
+		return zapcore.DPanicLevel
+	case 3:
+		return zapcore.ErrorLevel
+	case 4:
+		return zapcore.WarnLevel
+	case 7:
+		return zapcore.DebugLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+const (
+	// syslogTimeFormat is the RFC 5424 TIMESTAMP with microseconds
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	// bsdTimeFormat is the RFC 3164 TIMESTAMP
+	bsdTimeFormat = time.Stamp
+)
+
+// syslogSink writes entries as syslog messages.
+//
+// RFC 5424 messages (the default) carry the entry's message as MSG and its
+// fields as the SD-ELEMENT SyslogSDID; nested objects are flattened with
+// dots. RFC 3164 messages carry the encoded entry as MSG. Over TCP, RFC 5424
+// messages are framed with octet counting and RFC 3164 messages end with a
+// newline (RFC 6587).
+//
+// URLs: syslog://host:514 (UDP), syslog+udp://, syslog+tcp://host:port and
+// syslog+unix:///dev/log, with optional query parameters facility (name or
+// number, default user), app (default: program name), hostname and format
+// (rfc5424 or rfc3164).
+type syslogSink struct {
+	conn     *netSink
+	stream   bool
+	bsd      bool
+	facility int
+	hostname string
+	appName  string
+	procID   string
+}
+
+func newSyslogSinkFromURL(u *url.URL) (Sink, error) {
+	var network, address string
+	switch strings.ToLower(u.Scheme) {
+	case "syslog", "syslog+udp":
+		network, address = "udp", u.Host
+	case "syslog+tcp":
+		network, address = "tcp", u.Host
+	case "syslog+unix":
+		network, address = "unixgram", u.Path
+	default:
+		return nil, fmt.Errorf("unknown syslog scheme %q", u.Scheme)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("%s sink URL has no address", u.Scheme)
+	}
+
+	query := u.Query()
+	facility := syslogFacilities["user"]
+	if name := query.Get("facility"); name != "" {
+		var ok bool
+		if facility, ok = syslogFacilities[strings.ToLower(name)]; !ok {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 0 || n > 23 {
+				return nil, fmt.Errorf("unknown syslog facility %q", name)
+			}
+			facility = n
+		}
+	}
+
+	var bsd bool
+	switch format := strings.ToLower(query.Get("format")); format {
+	case "", "rfc5424":
+	case "rfc3164":
+		bsd = true
+	default:
+		return nil, fmt.Errorf("unknown syslog format %q", format)
+	}
+
+	hostname := query.Get("hostname")
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	appName := query.Get("app")
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+	appLength := 48
+	if bsd {
+		appLength = 32
+	}
+
+	return &syslogSink{
+		conn:     newNetSink(network, address),
+		stream:   network == "tcp",
+		bsd:      bsd,
+		facility: facility,
+		hostname: syslogHeaderValue(hostname, 255),
+		appName:  syslogHeaderValue(appName, appLength),
+		procID:   strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+// syslogHeaderValue makes a header field valid: printable ASCII without
+// spaces, at most max bytes, "-" when empty
+func syslogHeaderValue(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// format builds the syslog message for an entry
+func (s *syslogSink) format(ent zapcore.Entry, fields []zapcore.Field, p []byte) []byte {
+	priority := s.facility*8 + syslogSeverity(ent.Level)
+
+	var message []byte
+	if s.bsd {
+		header := fmt.Sprintf("<%d>%s %s %s[%s]: ",
+			priority, ent.Time.Format(bsdTimeFormat), s.hostname, s.appName, s.procID)
+		message = append([]byte(header), bytes.TrimRight(p, "\n")...)
+		if s.stream {
+			message = append(message, '\n')
+		}
+		return message
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %s - %s",
+		priority, ent.Time.Format(syslogTimeFormat), s.hostname, s.appName, s.procID, structuredData(ent, fields))
+	message = []byte(header)
+	if ent.Message != "" {
+		message = append(message, ' ')
+		message = append(message, ent.Message...)
+	}
+
+	if s.stream {
+		return append([]byte(strconv.Itoa(len(message))+" "), message...)
+	}
+	return message
+}
+
+func (s *syslogSink) WriteEntry(ent zapcore.Entry, p []byte) error {
+	return s.WriteFields(ent, nil, p)
+}
+
+func (s *syslogSink) WriteFields(ent zapcore.Entry, fields []zapcore.Field, p []byte) error {
+	return s.conn.write(s.format(ent, fields, p))
+}
+
+func (s *syslogSink) Sync() error {
+	return s.conn.Sync()
+}
+
+func (s *syslogSink) Close() error {
+	return s.conn.Close()
+}
+
+// structuredData encodes the fields, and the stack trace if any, as one
+// SD-ELEMENT, or "-" when there are none
+func structuredData(ent zapcore.Entry, fields []zapcore.Field) string {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	params := make(map[string]string, len(encoder.Fields)+1)
+	flattenParams(params, "", encoder.Fields)
+	if ent.Stack != "" {
+		params["stacktrace"] = ent.Stack
+	}
+	if len(params) == 0 {
+		return "-"
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sd strings.Builder
+	sd.WriteString("[" + SyslogSDID)
+	for _, name := range names {
+		sd.WriteString(" " + sdName(name) + `="`)
+		sdEscaper.WriteString(&sd, params[name])
+		sd.WriteString(`"`)
+	}
+	sd.WriteString("]")
+	return sd.String()
+}
+
+// flattenParams turns nested objects into dotted parameter names
+func flattenParams(params map[string]string, prefix string, fields map[string]interface{}) {
+	for key, value := range fields {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenParams(params, prefix+key+".", nested)
+			continue
+		}
+		params[prefix+key] = sdValue(value)
+	}
+}
+
+// sdValue formats a field value as a parameter value; anything but strings,
+// times and durations is written as JSON
+func sdValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	}
+	if encoded, err := json.Marshal(value); err == nil {
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
+
+// sdName makes a PARAM-NAME valid: printable ASCII except '=', ']' and '"',
+// at most 32 bytes
+func sdName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// sdEscaper escapes the characters RFC 5424 reserves in PARAM-VALUE
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// ParseSyslog parses one syslog message, RFC 5424 or RFC 3164 (with or
+// without PRI, as written to files by rsyslog), into a log entry with the
+// keys of DefaultSchema, so the filter helpers apply:
+//
+//   - timestamp, level and message from the header and MSG
+//   - hostname, app_name, proc_id, msg_id and facility when present
+//   - the parameters of SyslogSDID as top-level fields, numbers and booleans
+//     restored; other SD-ELEMENTs as objects keyed by their SD-ID
+//   - a MSG that is a JSON object (optionally after "@cee:") merged in, so
+//     entries sent with format=rfc3164 come back whole
+//
+// Octet-counting frames ("123 <14>1 ...") are accepted.
+func ParseSyslog(line []byte) (map[string]interface{}, error) {
+	p := &syslogParser{data: string(bytes.TrimRight(line, "\r\n"))}
+	log := make(map[string]interface{})
+
+	if n := strings.IndexByte(p.data, ' '); n > 0 && strings.HasPrefix(p.data[n+1:], "<") {
+		if _, err := strconv.Atoi(p.data[:n]); err == nil {
+			p.data = p.data[n+1:]
+		}
+	}
+
+	if strings.HasPrefix(p.data, "<") {
+		end := strings.IndexByte(p.data, '>')
+		if end < 2 {
+			return nil, errors.New("invalid syslog PRI")
+		}
+		priority, err := strconv.Atoi(p.data[1:end])
+		if err != nil || priority < 0 || priority > 191 {
+			return nil, errors.New("invalid syslog PRI")
+		}
+		p.data = p.data[end+1:]
+		log["level"] = syslogLevel(priority % 8).String()
+		log["facility"] = syslogFacilityName(priority / 8)
+	}
+
+	var err error
+	if strings.HasPrefix(p.data, "1 ") {
+		p.data = p.data[2:]
+		err = p.parse5424(log)
+	} else {
+		err = p.parse3164(log)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg := strings.TrimPrefix(p.data, "\ufeff")
+	if object := strings.TrimSpace(strings.TrimPrefix(msg, "@cee:")); strings.HasPrefix(object, "{") {
+		decoder := json.NewDecoder(strings.NewReader(object))
+		decoder.UseNumber()
+		var fields map[string]interface{}
+		if decoder.Decode(&fields) == nil && !decoder.More() {
+			for key, value := range fields {
+				log[key] = value
+			}
+			return log, nil
+		}
+	}
+	log["message"] = msg
+	return log, nil
+}
+
+// NewSyslogReader creates a reader over syslog messages, one per line,
+// parsed with ParseSyslog. Only entries accepted by filter are returned
+// (nil = all entries).
+func NewSyslogReader(r io.Reader, filter FilterFunc) *Reader {
+	reader := NewReader(r, filter)
+	reader.parse = ParseSyslog
+	return reader
+}
+
+// syslogFacilityName returns the name of a facility code
+func syslogFacilityName(code int) string {
+	for name, c := range syslogFacilities {
+		if c == code {
+			return name
+		}
+	}
+	return strconv.Itoa(code)
+}
+
+// syslogParser consumes a message from the front
+type syslogParser struct {
+	data string
+}
+
+// field consumes a space-terminated header field
+func (p *syslogParser) field() (string, error) {
+	end := strings.IndexByte(p.data, ' ')
+	if end < 0 {
+		end = len(p.data)
+	}
+	value := p.data[:end]
+	if value == "" {
+		return "", errors.New("truncated syslog header")
+	}
+	p.data = strings.TrimPrefix(p.data[end:], " ")
+	return value, nil
+}
+
+// parse5424 reads TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA
+func (p *syslogParser) parse5424(log map[string]interface{}) error {
+	var header [5]string
+	for i := range header {
+		value, err := p.field()
+		if err != nil {
+			return err
+		}
+		header[i] = value
+	}
+
+	if header[0] != "-" {
+		t, err := time.Parse(time.RFC3339Nano, header[0])
+		if err != nil {
+			return fmt.Errorf("invalid syslog timestamp: %w", err)
+		}
+		log["timestamp"] = t.Format(time.RFC3339Nano)
+	}
+	for i, key := range []string{"hostname", "app_name", "proc_id", "msg_id"} {
+		if value := header[i+1]; value != "-" {
+			log[key] = value
+		}
+	}
+
+	if strings.HasPrefix(p.data, "-") {
+		p.data = strings.TrimPrefix(p.data[1:], " ")
+		return nil
+	}
+	for strings.HasPrefix(p.data, "[") {
+		if err := p.element(log); err != nil {
+			return err
+		}
+	}
+	if p.data != "" && p.data[0] != ' ' {
+		return errors.New("invalid syslog structured data")
+	}
+	p.data = strings.TrimPrefix(p.data, " ")
+	return nil
+}
+
+// element reads one SD-ELEMENT
+func (p *syslogParser) element(log map[string]interface{}) error {
+	p.data = p.data[1:]
+	end := strings.IndexAny(p.data, " ]")
+	if end <= 0 {
+		return errors.New("invalid syslog SD-ID")
+	}
+	id := p.data[:end]
+	p.data = p.data[end:]
+
+	params := log
+	if id != SyslogSDID {
+		params = make(map[string]interface{})
+		log[id] = params
+	}
+
+	for {
+		switch {
+		case strings.HasPrefix(p.data, "]"):
+			p.data = p.data[1:]
+			return nil
+		case strings.HasPrefix(p.data, " "):
+			p.data = p.data[1:]
+		default:
+			return errors.New("invalid syslog SD-ELEMENT")
+		}
+
+		eq := strings.Index(p.data, `="`)
+		if eq <= 0 {
+			return errors.New("invalid syslog SD-PARAM")
+		}
+		name := p.data[:eq]
+		p.data = p.data[eq+2:]
+
+		var value strings.Builder
+		for {
+			if p.data == "" {
+				return errors.New("unterminated syslog PARAM-VALUE")
+			}
+			c := p.data[0]
+			p.data = p.data[1:]
+			if c == '"' {
+				break
+			}
+			if c == '\\' && p.data != "" && strings.IndexByte(`"\]`, p.data[0]) >= 0 {
+				c = p.data[0]
+				p.data = p.data[1:]
+			}
+			value.WriteByte(c)
+		}
+		params[name] = paramValue(value.String())
+	}
+}
+
+// paramValue restores numbers, booleans and JSON arrays and objects
+func paramValue(value string) interface{} {
+	switch {
+	case value == "true":
+		return true
+	case value == "false":
+		return false
+	case value == "":
+		return value
+	}
+
+	switch c := value[0]; {
+	case c == '-' || (c >= '0' && c <= '9'):
+		var n json.Number
+		if json.Unmarshal([]byte(value), &n) == nil {
+			return n
+		}
+	case c == '[' || c == '{':
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		var decoded interface{}
+		if decoder.Decode(&decoded) == nil && !decoder.More() {
+			return decoded
+		}
+	}
+	return value
+}
+
+// parse3164 reads TIMESTAMP HOSTNAME TAG[PID]: where the timestamp is
+// "Jan _2 15:04:05" or RFC 3339
+func (p *syslogParser) parse3164(log map[string]interface{}) error {
+	var t time.Time
+	if len(p.data) >= len(bsdTimeFormat) {
+		if parsed, err := time.ParseInLocation(bsdTimeFormat, p.data[:len(bsdTimeFormat)], time.Local); err == nil {
+			// The year is not sent; pick the one that is not in the future
+			now := time.Now()
+			t = parsed.AddDate(now.Year(), 0, 0)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			p.data = strings.TrimPrefix(p.data[len(bsdTimeFormat):], " ")
+		}
+	}
+	if t.IsZero() {
+		value, err := p.field()
+		if err != nil {
+			return err
+		}
+		if t, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return fmt.Errorf("invalid syslog timestamp: %w", err)
+		}
+	}
+	log["timestamp"] = t.Format(time.RFC3339Nano)
+
+	hostname, err := p.field()
+	if err != nil {
+		return err
+	}
+	log["hostname"] = hostname
+
+	// The tag ends at the first ':' or '[' ahead of the MSG; messages
+	// without one are all MSG
+	end := strings.IndexAny(p.data, ":[ ")
+	if end <= 0 || p.data[end] == ' ' {
+		return nil
+	}
+	log["app_name"] = p.data[:end]
+	rest := p.data[end:]
+	if strings.HasPrefix(rest, "[") {
+		closing := strings.Index(rest, "]")
+		if closing < 0 {
+			return nil
+		}
+		log["proc_id"] = rest[1:closing]
+		rest = rest[closing+1:]
+	}
+	p.data = strings.TrimPrefix(strings.TrimPrefix(rest, ":"), " ")
+	return nil
+}
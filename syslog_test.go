@@ -0,0 +1,249 @@
+package jsonlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// request is a nested object field
+type request struct {
+	method, path string
+}
+
+func (r request) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("method", r.method)
+	enc.AddString("path", r.path)
+	return nil
+}
+
+func TestSyslogSinkStructuredData(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Octet counting: "<length> <message>"
+		reader := bufio.NewReader(conn)
+		length, _ := reader.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		message := make([]byte, n)
+		if _, err := io.ReadFull(reader, message); err == nil {
+			received <- string(message)
+		}
+	}()
+
+	logger, err := NewLogger(Config{
+		LogPath: t.TempDir(),
+		Sinks:   []SinkConfig{{URL: "syslog+tcp://" + listener.Addr().String() + "?app=api"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Error("request failed",
+		zap.Object("http", request{method: "GET", path: `/a"b]`}),
+		zap.Int("status", 502),
+		zap.Bool("retry", true),
+		zap.Strings("hops", []string{"lb", "api"}),
+		zap.Error(errors.New("upstream closed")),
+	)
+
+	var message string
+	select {
+	case message = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("syslog sink sent nothing")
+	}
+
+	if !strings.Contains(message, `http.path="/a\"b\]"`) {
+		t.Errorf("reserved characters not escaped: %q", message)
+	}
+
+	entry, err := ParseSyslog([]byte(message))
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", message, err)
+	}
+	if entry["level"] != "error" || entry["message"] != "request failed" || entry["app_name"] != "api" || entry["facility"] != "user" {
+		t.Errorf("unexpected header fields: %v", entry)
+	}
+	if entry["http.path"] != `/a"b]` || entry["error"] != "upstream closed" || entry["retry"] != true {
+		t.Errorf("unexpected fields: %v", entry)
+	}
+	if hops, ok := entry["hops"].([]interface{}); !ok || len(hops) != 2 {
+		t.Errorf("array field not restored: %v", entry["hops"])
+	}
+	if !FieldGreaterOrEqual("status", 500)(entry) || !FieldEquals("http.method", "GET")(entry) {
+		t.Errorf("filters do not match the parsed entry: %v", entry)
+	}
+}
+
+func TestSyslogSinkRFC3164(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", socket)
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	logger, err := NewLogger(Config{
+		LogPath: t.TempDir(),
+		Sinks:   []SinkConfig{{URL: "syslog+unix://" + socket + "?format=rfc3164&facility=daemon&hostname=web1"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("user signed in", zap.String("user", "alice"))
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("syslog sink sent nothing: %v", err)
+	}
+	message := string(buf[:n])
+
+	// daemon (3) * 8 + informational (6)
+	if !strings.HasPrefix(message, "<30>") || !strings.Contains(message, " web1 ") {
+		t.Errorf("unexpected header: %q", message)
+	}
+
+	entry, err := ParseSyslog(buf[:n])
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", message, err)
+	}
+	if entry["message"] != "user signed in" || entry["user"] != "alice" || entry["hostname"] != "web1" || entry["facility"] != "daemon" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+	if _, ok := entry["caller"]; !ok {
+		t.Errorf("the encoded entry should come back whole: %v", entry)
+	}
+}
+
+func TestParseSyslog(t *testing.T) {
+	year := time.Now().Year()
+
+	tests := []struct {
+		name string
+		line string
+		want map[string]interface{}
+	}{
+		{
+			name: "rfc5424 with foreign structured data",
+			line: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`,
+			want: map[string]interface{}{
+				"timestamp": "2003-10-11T22:14:15.003Z", "level": "info", "facility": "local4",
+				"hostname": "mymachine.example.com", "app_name": "evntslog", "msg_id": "ID47",
+				"exampleSDID@32473": map[string]interface{}{"iut": json.Number("3"), "eventSource": "Application"},
+				"message":           "An application event",
+			},
+		},
+		{
+			name: "rfc5424 without structured data or message",
+			line: `<12>1 2024-05-01T10:00:00Z host app 42 - -`,
+			want: map[string]interface{}{
+				"timestamp": "2024-05-01T10:00:00Z", "level": "warn", "facility": "user",
+				"hostname": "host", "app_name": "app", "proc_id": "42", "message": "",
+			},
+		},
+		{
+			name: "rfc3164",
+			line: `<34>Jan  1 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8`,
+			want: map[string]interface{}{
+				"timestamp": time.Date(year, 1, 1, 22, 14, 15, 0, time.Local).Format(time.RFC3339Nano),
+				"level":     "dpanic", "facility": "auth", "hostname": "mymachine", "app_name": "su",
+				"message": "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "rsyslog file format",
+			line: `2024-05-01T10:00:00.123456+00:00 web1 sshd[812]: Accepted publickey for deploy`,
+			want: map[string]interface{}{
+				"timestamp": "2024-05-01T10:00:00.123456Z", "hostname": "web1", "app_name": "sshd",
+				"proc_id": "812", "message": "Accepted publickey for deploy",
+			},
+		},
+		{
+			name: "cee json message",
+			line: `<14>1 2024-05-01T10:00:00Z web1 app - - - @cee: {"message":"from json","level":"debug","n":1}`,
+			want: map[string]interface{}{
+				"timestamp": "2024-05-01T10:00:00Z", "level": "debug", "facility": "user",
+				"hostname": "web1", "app_name": "app", "message": "from json", "n": json.Number("1"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSyslog([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("got  %s\nwant %s", gotJSON, wantJSON)
+			}
+		})
+	}
+
+	for _, line := range []string{
+		`<999>1 2024-05-01T10:00:00Z host app - - - msg`,
+		`<14>1 yesterday host app - - - msg`,
+		`<14>1 2024-05-01T10:00:00Z host app - - [broken msg`,
+		`<14>1 2024-05-01T10:00:00Z host`,
+	} {
+		if _, err := ParseSyslog([]byte(line)); err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
+
+func TestSyslogReader(t *testing.T) {
+	input := strings.Join([]string{
+		`<15>1 2024-05-01T10:00:00Z web1 api - - [fields@32473 latency_ms="12"] fast`,
+		`<11>1 2024-05-01T10:00:01Z web1 api - - [fields@32473 latency_ms="950"] slow`,
+		`not syslog at all`,
+		`<12>1 2024-05-01T10:00:02Z web1 api - - [fields@32473 latency_ms="700"] slowish`,
+	}, "\n")
+
+	var malformed int
+	reader := NewSyslogReader(strings.NewReader(input), And(
+		FilterByMinLevel(WarnLevel),
+		FieldGreaterThan("latency_ms", 500),
+	))
+	reader.OnMalformed = func(*MalformedLineError) { malformed++ }
+
+	var messages []string
+	for reader.Next() {
+		messages = append(messages, reader.Entry()["message"].(string))
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if strings.Join(messages, ",") != "slow,slowish" || malformed != 1 {
+		t.Errorf("unexpected entries %v, %d malformed", messages, malformed)
+	}
+}